@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic/console"
+)
+
+// A Speaker reads messages out to the table, so nobody has to watch the
+// terminal to know whose turn it is.
+type Speaker interface {
+	Speak(message string) error
+}
+
+// Speakers that can be chosen with --announcer. auto picks the first
+// speech program found on the PATH, and falls back to the terminal bell.
+var speakerNames = []string{"auto", "say", "espeak", "spd-say", "bell", "file", "none"}
+
+// speechPrograms are tried in order by the auto speaker. say is macOS;
+// the others are common on Linux.
+var speechPrograms = []string{"say", "spd-say", "espeak"}
+
+// NewSpeaker creates the speaker with the given name. file is only used by
+// the file speaker.
+func NewSpeaker(name string, file string) (Speaker, error) {
+	switch name {
+	case "auto":
+		for _, program := range speechPrograms {
+			if _, err := exec.LookPath(program); err == nil {
+				return speechSpeaker{program}, nil
+			}
+		}
+		return bellSpeaker{os.Stdout}, nil
+	case "say", "espeak", "spd-say":
+		if _, err := exec.LookPath(name); err != nil {
+			return nil, fmt.Errorf("%v is not installed", name)
+		}
+		return speechSpeaker{name}, nil
+	case "bell":
+		return bellSpeaker{os.Stdout}, nil
+	case "file":
+		if file == "" {
+			return nil, fmt.Errorf("The file speaker needs an --announce-file to write to")
+		}
+		return fileSpeaker{file}, nil
+	case "none":
+		return noSpeaker{}, nil
+	}
+	return nil, fmt.Errorf("Unknown speaker %v", name)
+}
+
+// speechSpeaker reads messages aloud with a program such as say or
+// espeak, which take the message to speak as their arguments.
+type speechSpeaker struct {
+	program string
+}
+
+// Speak starts reading the message out and returns straight away, so the
+// console isn't held up while the program talks. Only a failure to start
+// the program is reported.
+func (s speechSpeaker) Speak(message string) error {
+	cmd := exec.Command(s.program, message)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("Could not say message out loud with %v: %v", s.program, err)
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// bellSpeaker rings the terminal bell, for when there is no way to speak.
+type bellSpeaker struct {
+	out io.Writer
+}
+
+func (b bellSpeaker) Speak(message string) error {
+	_, err := fmt.Fprint(b.out, "\a")
+	return err
+}
+
+// fileSpeaker appends each message to a file, one per line.
+type fileSpeaker struct {
+	path string
+}
+
+func (f fileSpeaker) Speak(message string) error {
+	out, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "%v %v\n", time.Now().Format(time.RFC3339), message)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+type noSpeaker struct{}
+
+func (noSpeaker) Speak(message string) error {
+	return nil
+}
+
+// announcements decides which of the console's events are passed on to
+// the speaker.
+type announcements struct {
+	speaker Speaker
+	events  map[string]bool
+}
+
+// newAnnouncements announces only the events listed, separated by commas,
+// such as "turn,epidemic".
+func newAnnouncements(speaker Speaker, events string) (*announcements, error) {
+	a := &announcements{speaker, map[string]bool{}}
+	for _, event := range strings.Split(events, ",") {
+		event = strings.TrimSpace(event)
+		if event == "" {
+			continue
+		}
+		known := false
+		for _, name := range console.AnnouncementEvents {
+			known = known || name == event
+		}
+		if !known {
+			return nil, fmt.Errorf("Unknown announcement %v, expected one of %v", event, strings.Join(console.AnnouncementEvents, ", "))
+		}
+		a.events[event] = true
+	}
+	return a, nil
+}
+
+func (a *announcements) Announce(event string, message string) error {
+	if !a.events[event] {
+		return nil
+	}
+	return a.speaker.Speak(message)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic/console"
+)
+
+type recordingSpeaker struct {
+	messages []string
+}
+
+func (r *recordingSpeaker) Speak(message string) error {
+	r.messages = append(r.messages, message)
+	return nil
+}
+
+func TestNewAnnouncementsRejectsUnknownEvents(t *testing.T) {
+	if _, err := newAnnouncements(&recordingSpeaker{}, "turn,earthquake"); err == nil || !strings.Contains(err.Error(), "earthquake") {
+		t.Fatalf("Expected earthquake to be rejected, got %v", err)
+	}
+}
+
+func TestNewAnnouncementsSkipsBlanks(t *testing.T) {
+	a, err := newAnnouncements(&recordingSpeaker{}, " turn, ,epidemic,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.events) != 2 || !a.events[console.TurnAnnouncement] || !a.events[console.EpidemicAnnouncement] {
+		t.Fatalf("Expected only turn and epidemic, got %v", a.events)
+	}
+	if a, err = newAnnouncements(&recordingSpeaker{}, ""); err != nil || len(a.events) != 0 {
+		t.Fatalf("Expected no events to be announced, got %v, %v", a, err)
+	}
+}
+
+func TestAnnouncementsFilterEvents(t *testing.T) {
+	speaker := &recordingSpeaker{}
+	a, err := newAnnouncements(speaker, "turn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Announce(console.TurnAnnouncement, "Will")
+	a.Announce(console.OutbreakAnnouncement, "Outbreak in lagos")
+	if len(speaker.messages) != 1 || speaker.messages[0] != "Will" {
+		t.Fatalf("Expected only the turn to be spoken, got %v", speaker.messages)
+	}
+}
+
+func TestFileSpeaker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "speaker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "announcements.txt")
+
+	if _, err = NewSpeaker("file", ""); err == nil {
+		t.Fatal("Expected the file speaker to need a file")
+	}
+	speaker, err := NewSpeaker("file", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, message := range []string{"Will", "Epidemic in lagos"} {
+		if err = speaker.Speak(message); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], " Will") || !strings.HasSuffix(lines[1], " Epidemic in lagos") {
+		t.Fatalf("Expected one timestamped line per message, got %q", data)
+	}
+}
+
+func TestSpeechSpeakerDoesNotWait(t *testing.T) {
+	start := time.Now()
+	if err := (speechSpeaker{"sleep"}).Speak("5"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Expected speaking to return before the message finished, took %v", elapsed)
+	}
+	if err := (speechSpeaker{"no-such-speech-program"}).Speak("Will"); err == nil {
+		t.Fatal("Expected a missing program to be reported")
+	}
+}
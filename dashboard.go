@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic"
+)
+
+// A broadcaster wakes every dashboard watching the game when it changes.
+type broadcaster struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]bool
+}
+
+func newBroadcaster() *broadcaster {
+	return &broadcaster{clients: map[chan struct{}]bool{}}
+}
+
+func (b *broadcaster) subscribe() chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// a buffer of one is enough, since a dashboard only needs to know
+	// that something changed since it last redrew.
+	c := make(chan struct{}, 1)
+	b.clients[c] = true
+	return c
+}
+
+func (b *broadcaster) unsubscribe(c chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.clients, c)
+}
+
+func (b *broadcaster) notify() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for c := range b.clients {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// dashboardState is everything the dashboard shows, laid out the same way
+// as the terminal.
+type dashboardState struct {
+	GameName      string                    `json:"game_name"`
+	Player        string                    `json:"player"`
+	Phase         pandemic.TurnPhase        `json:"phase"`
+	InfectionRate int                       `json:"infection_rate"`
+	Outbreaks     int                       `json:"outbreaks"`
+	Striations    [][]cityProbability       `json:"striations"`
+	Drawn         []cityProbability         `json:"drawn"`
+	Epidemics     pandemic.EpidemicAnalysis `json:"epidemics"`
+	Players       []playerCuring            `json:"players"`
+}
+
+func (s *apiServer) dashboardState() (*dashboardState, error) {
+	cur, err := s.game.GameTurns.CurrentTurn()
+	if err != nil {
+		return nil, err
+	}
+	state := &dashboardState{
+		GameName:      s.game.GameName,
+		Player:        cur.Player.HumanName,
+		Phase:         cur.Phase(s.game.InfectionRate),
+		InfectionRate: s.game.InfectionRate,
+		Outbreaks:     s.game.Outbreaks,
+		Striations:    [][]cityProbability{},
+		Epidemics:     s.game.CityDeck.EpidemicAnalysis(),
+		Players:       s.playersCuring(),
+	}
+	// the bottom of the infection deck comes first, as the leftmost
+	// column in the terminal.
+	for i := len(s.game.InfectionDeck.Striations) - 1; i >= 0; i-- {
+		cities, err := s.citiesBySeverity(s.game.InfectionDeck.CitiesInStriation(i))
+		if err != nil {
+			return nil, err
+		}
+		state.Striations = append(state.Striations, cities)
+	}
+	state.Drawn, err = s.citiesBySeverity(s.game.InfectionDeck.CitiesInDrawn())
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (s *apiServer) citiesBySeverity(names []pandemic.CityName) ([]cityProbability, error) {
+	cities := []cityProbability{}
+	for _, name := range s.game.SortBySeverity(names) {
+		city, err := s.game.GetCity(name)
+		if err != nil {
+			return nil, err
+		}
+		cities = append(cities, s.cityProbability(city))
+	}
+	return cities, nil
+}
+
+func (s *apiServer) handleDashboardPage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	if !allowMethod(w, r, "GET") {
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	io.WriteString(w, dashboardPage)
+}
+
+func (s *apiServer) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "GET") {
+		return
+	}
+	s.view.mu.Lock()
+	defer s.view.mu.Unlock()
+	state, err := s.dashboardState()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, state)
+}
+
+// handleEvents streams the dashboard state as server-sent events, once
+// when the dashboard connects and again after every command.
+func (s *apiServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "GET") {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("Streaming is not supported"))
+		return
+	}
+	changes := s.view.changes.subscribe()
+	defer s.view.changes.unsubscribe(changes)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	for {
+		if err := s.sendState(w); err != nil {
+			s.view.logger.Errorf("Could not send dashboard state: %v", err)
+			return
+		}
+		flusher.Flush()
+		select {
+		case <-changes:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *apiServer) sendState(w io.Writer) error {
+	s.view.mu.Lock()
+	state, err := s.dashboardState()
+	s.view.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: state\ndata: %s\n\n", data)
+	return err
+}
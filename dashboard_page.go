@@ -0,0 +1,138 @@
+package main
+
+// dashboardPage is the single page served by the dashboard command. It
+// draws whatever /api/events sends, so it holds no game logic of its own.
+const dashboardPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Pandemic NeRd hUrD</title>
+<style>
+body { background: #111; color: #ddd; font-family: Menlo, monospace; margin: 0.5em; }
+h1 { font-size: 1.2em; margin: 0.2em 0; }
+h2 { font-size: 1em; margin: 0.5em 0 0.2em; }
+.status { margin-bottom: 0.5em; }
+.columns { display: flex; flex-wrap: wrap; gap: 0.5em; }
+.column { flex: 1; min-width: 10em; border: 1px solid #444; padding: 0.3em; }
+.city { white-space: nowrap; }
+.safe { color: #3c3; }
+.warning { color: #dd3; }
+.danger { color: #000; background: #d33; }
+.panels { display: flex; flex-wrap: wrap; gap: 0.5em; margin-top: 0.5em; }
+.panel { flex: 1; min-width: 16em; border: 1px solid #444; padding: 0.3em; }
+.current { color: #000; background: #ddd; }
+#connection { float: right; font-size: 0.8em; }
+</style>
+</head>
+<body>
+<span id="connection">connecting</span>
+<h1 id="title">Pandemic Legacy</h1>
+<div class="status" id="status"></div>
+<div class="columns" id="striations"></div>
+<div class="panels">
+  <div class="panel"><h2>City Deck</h2><div id="epidemics"></div></div>
+  <div class="panel"><h2>Players</h2><div id="players"></div></div>
+</div>
+<script>
+var icons = {Yellow: "💛", Blue: "💙", Red: "❤️", Black: "⚫", Faded: "😈"};
+
+function el(tag, className, text) {
+  var node = document.createElement(tag);
+  if (className) node.className = className;
+  if (text !== undefined) node.textContent = text;
+  return node;
+}
+
+function icon(disease) {
+  return icons[disease] || disease;
+}
+
+// colored the same way as printCityWithProb in the terminal
+function cityRow(city) {
+  var cubes = new Array(city.infections + 1).join("•");
+  var status = (city.quarantined ? "⛔" : "") + (city.research_station ? "🏥" : "");
+  var text = city.city.slice(0, 4) + " " + icon(city.disease) + " " + cubes + " " + status + " " +
+    city.probability.toFixed(2) + " " + city.cube_probability.toFixed(2);
+  var className = "warning";
+  if (city.cube_probability === 0) className = "safe";
+  else if (city.can_outbreak) className = "danger";
+  return el("div", "city " + className, text);
+}
+
+function column(title, cities) {
+  var node = el("div", "column");
+  node.appendChild(el("h2", "", title));
+  cities.forEach(function (city) { node.appendChild(cityRow(city)); });
+  return node;
+}
+
+function percent(p) {
+  var className = p === 0 ? "safe" : (p > 0.5 ? "danger" : "warning");
+  return el("span", className, p.toFixed(3));
+}
+
+// colored the same way as colorProbabilityOfCure in the terminal
+function cure(p) {
+  var className = p < 0.2 ? "danger" : (p < 0.8 ? "warning" : "safe");
+  return el("span", className, p.toFixed(2));
+}
+
+function line(label, value) {
+  var node = el("div", "", label + " ");
+  node.appendChild(typeof value === "object" ? value : document.createTextNode(value));
+  return node;
+}
+
+function render(state) {
+  document.getElementById("title").textContent = "Pandemic Legacy: " + state.game_name;
+  document.getElementById("status").textContent = state.player + "'s turn, " + state.phase +
+    " phase. Infection rate " + state.infection_rate + ", " + state.outbreaks + " outbreaks.";
+
+  var striations = document.getElementById("striations");
+  striations.innerHTML = "";
+  var count = state.striations.length;
+  state.striations.forEach(function (cities, i) {
+    striations.appendChild(column("Infection " + (count - i - 1), cities));
+  });
+  striations.appendChild(column("Infection Drawn", state.drawn));
+
+  var e = state.epidemics;
+  var epidemics = document.getElementById("epidemics");
+  epidemics.innerHTML = "";
+  epidemics.appendChild(line("Epidemic this turn:", percent(e.first_card_probability + e.second_card_probability)));
+  var guarantee = el("div", e.scenarios_with_100 > 0 ? "danger" : "",
+    e.scenarios_with_100 + " of " + e.possible_scenarios + " scenarios guarantee epidemic");
+  epidemics.appendChild(guarantee);
+  epidemics.appendChild(line("Epidemic on first city:", percent(e.first_card_probability)));
+  epidemics.appendChild(line("Epidemic on second city:", percent(e.second_card_probability)));
+  epidemics.appendChild(line(" -> after first city epidemic:", percent(e.second_card_epi_after_first_epi)));
+  epidemics.appendChild(line("Upcoming draws guaranteed safe:", String(e.coming_draws_with_0)));
+
+  var players = document.getElementById("players");
+  players.innerHTML = "";
+  state.players.forEach(function (player) {
+    var node = el("div");
+    node.appendChild(el("div", player.current ? "current" : "",
+      player.player + (player.location ? " in " + player.location : "")));
+    node.appendChild(el("div", "", "Cards: " + player.cards.join(", ")));
+    Object.keys(player.curing).sort().forEach(function (disease) {
+      node.appendChild(line(icon(disease) + " ⚗", cure(player.curing[disease])));
+    });
+    players.appendChild(node);
+  });
+}
+
+var connection = document.getElementById("connection");
+var events = new EventSource("/api/events");
+events.addEventListener("state", function (message) {
+  connection.textContent = "live";
+  render(JSON.parse(message.data));
+});
+events.onerror = function () {
+  connection.textContent = "reconnecting";
+};
+</script>
+</body>
+</html>
+`
@@ -1,47 +1,94 @@
 package main
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic"
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic/console"
 
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
+var months = []string{
+	"jan",
+	"feb",
+	"mar",
+	"apr",
+	"may",
+	"jun",
+	"jul",
+	"aug",
+	"sep",
+	"oct",
+	"nov",
+	"dec",
+	"jan2",
+	"feb2",
+	"mar2",
+	"apr2",
+	"may2",
+	"jun2",
+	"jul2",
+	"aug2",
+	"sep2",
+	"oct2",
+	"nov2",
+	"dec2",
+}
+
 var (
 	app              = kingpin.New("pandemic–nerd-hurd", "Start a nerd herd game")
 	startCmd         = app.Command("start", "Start a new game")
 	startNewGameFile = startCmd.Flag("new-game-file", "The file containing initial data about Cities, Players and Funded Events.").Default("data/new_game.json").ExistingFile()
-	startMonth       = startCmd.Flag("month", "The name of the month in the game we are playing. If playing the second time in a month, add '2' after the name").Required().Enum(
-		"jan",
-		"feb",
-		"mar",
-		"apr",
-		"may",
-		"jun",
-		"jul",
-		"aug",
-		"sep",
-		"oct",
-		"nov",
-		"dec",
-		"jan2",
-		"feb2",
-		"mar2",
-		"apr2",
-		"may2",
-		"jun2",
-		"jul2",
-		"aug2",
-		"sep2",
-		"oct2",
-		"nov2",
-		"dec2",
-	)
-	loadCmd  = app.Command("load", "Load a game from an existing saved game")
-	loadFile = loadCmd.Flag("file", "The JSON file containing the game state").Required().ExistingFile()
+	startMonth       = startCmd.Flag("month", "The name of the month in the game we are playing. If playing the second time in a month, add '2' after the name").Required().Enum(months...)
+	loadCmd          = app.Command("load", "Load a game from an existing saved game")
+	loadFile         = loadCmd.Flag("file", "The JSON file containing the game state, or a game's journal.jsonl").ExistingFile()
+	loadLatest       = loadCmd.Flag("latest", "Load the newest readable snapshot in --dir").Bool()
+	loadDir          = loadCmd.Flag("dir", "The folder containing the game's snapshots, such as aug").ExistingDir()
+
+	serveCmd    = app.Command("serve", "Play a saved game in the terminal and share it over a local HTTP API")
+	serveFile   = serveCmd.Flag("file", "The JSON file containing the game state, or a game's journal.jsonl").ExistingFile()
+	serveLatest = serveCmd.Flag("latest", "Load the newest readable snapshot in --dir").Bool()
+	serveDir    = serveCmd.Flag("dir", "The folder containing the game's snapshots, such as aug").ExistingDir()
+	serveAddr   = serveCmd.Flag("addr", "The loopback address to serve the API on").Default("127.0.0.1:8080").String()
+
+	dashboardCmd    = app.Command("dashboard", "Play a saved game in the terminal and show it live in a browser")
+	dashboardFile   = dashboardCmd.Flag("file", "The JSON file containing the game state, or a game's journal.jsonl").ExistingFile()
+	dashboardLatest = dashboardCmd.Flag("latest", "Load the newest readable snapshot in --dir").Bool()
+	dashboardDir    = dashboardCmd.Flag("dir", "The folder containing the game's snapshots, such as aug").ExistingDir()
+	dashboardAddr   = dashboardCmd.Flag("addr", "The loopback address to serve the dashboard on").Default("127.0.0.1:8080").String()
+
+	runCmd         = app.Command("run", "Apply commands from a script or stdin to a game, without the terminal")
+	runScriptFile  = runCmd.Flag("script", "The file of commands to run, one per line, or - for stdin").Default("-").String()
+	runFile        = runCmd.Flag("file", "The JSON file containing the game state, or a game's journal.jsonl").ExistingFile()
+	runLatest      = runCmd.Flag("latest", "Load the newest readable snapshot in --dir").Bool()
+	runDir         = runCmd.Flag("dir", "The folder containing the game's snapshots, such as aug").ExistingDir()
+	runMonth       = runCmd.Flag("month", "Start a new game for this month instead of loading one").Enum(months...)
+	runNewGameFile = runCmd.Flag("new-game-file", "The file containing initial data for a new game").Default("data/new_game.json").ExistingFile()
+	runDryRun      = runCmd.Flag("dry-run", "Keep the journal in memory instead of saving the commands").Bool()
+	runFormat      = runCmd.Flag("format", "Print the results as text or as JSON").Default("text").Enum("text", "json")
+
+	replayCmd = app.Command("replay", "Step through the snapshots saved while playing a game")
+	replayDir = replayCmd.Flag("dir", "The folder containing the game's snapshots, such as aug").Required().ExistingDir()
+
+	statsCmd    = app.Command("stats", "Report statistics across every month played so far")
+	statsDir    = statsCmd.Flag("dir", "The folder containing a folder for each month").Default(".").ExistingDir()
+	statsFormat = statsCmd.Flag("format", "Print the report as a table or as JSON").Default("table").Enum("table", "json")
+
+	simulateCmd  = app.Command("simulate", "Play the rest of a saved game forward many times with random draws")
+	simulateDir  = simulateCmd.Flag("dir", "The folder containing the game's journal or snapshots").Required().ExistingDir()
+	simulateRuns = simulateCmd.Flag("runs", "How many games to simulate").Default("5000").Int()
+	simulateSeed = simulateCmd.Flag("seed", "Seed for the random number generator").Default("1").Int64()
+
+	announcerName  = app.Flag("announcer", "How to announce turns and other events: "+strings.Join(speakerNames, ", ")).Default("auto").Enum(speakerNames...)
+	announceFile   = app.Flag("announce-file", "The file the file speaker appends messages to").String()
+	announceEvents = app.Flag("announce", "The events to announce, separated by commas: "+strings.Join(console.AnnouncementEvents, ", ")).Default(console.TurnAnnouncement).String()
 )
 
 func main() {
@@ -52,7 +99,42 @@ func main() {
 	logger.Out = fd
 	wd, _ := os.Getwd()
 
+	if cmd == "stats" {
+		err = printStats(os.Stdout, filepath.Join(wd, *statsDir), *statsFormat)
+		app.FatalIfError(err, "Could not report stats")
+		return
+	}
+
+	if cmd == "simulate" {
+		err = printSimulation(os.Stdout, filepath.Join(wd, *simulateDir), *simulateRuns, *simulateSeed)
+		app.FatalIfError(err, "Could not simulate")
+		return
+	}
+
+	if cmd == "replay" {
+		steps, err := pandemic.LoadReplay(filepath.Join(wd, *replayDir))
+		if err != nil {
+			logger.Fatalln(err)
+		}
+		NewView(logger, nil).StartReplay(steps)
+		return
+	}
+
+	if cmd == "run" {
+		journal, gameState, err := openScriptGame(wd)
+		app.FatalIfError(err, "Could not load game")
+		defer journal.Close()
+		dispatcher := console.NewDispatcher(gameState, journal)
+		err = runScript(os.Stdout, *runScriptFile, dispatcher, *runFormat)
+		if err != nil {
+			journal.Close()
+			app.Fatalf("%v", err)
+		}
+		return
+	}
+
 	var gameState *pandemic.GameState
+	var journal *pandemic.Journal
 
 	switch cmd {
 	case "start":
@@ -60,13 +142,152 @@ func main() {
 		if err != nil {
 			logger.Fatalln(err)
 		}
-	case "load":
-		gameState, err = pandemic.LoadGame(filepath.Join(wd, *loadFile))
+		journal, err = createJournal(gameState)
 		if err != nil {
 			logger.Fatalln(err)
 		}
+	case "load":
+		journal, gameState, err = openGame(wd, *loadFile, *loadLatest, *loadDir, false)
+		app.FatalIfError(err, "Could not load game")
+	case "serve":
+		journal, gameState, err = openGame(wd, *serveFile, *serveLatest, *serveDir, false)
+		app.FatalIfError(err, "Could not load game")
+	case "dashboard":
+		journal, gameState, err = openGame(wd, *dashboardFile, *dashboardLatest, *dashboardDir, false)
+		app.FatalIfError(err, "Could not load game")
 	}
+	defer journal.Close()
 
-	view := NewView(logger)
+	dispatcher := console.NewDispatcher(gameState, journal)
+	dispatcher.History, err = openHistory(gameState)
+	app.FatalIfError(err, "Could not load command history")
+	defer dispatcher.History.Close()
+	speaker, err := NewSpeaker(*announcerName, *announceFile)
+	app.FatalIfError(err, "Could not set up announcements")
+	dispatcher.Announcer, err = newAnnouncements(speaker, *announceEvents)
+	app.FatalIfError(err, "Could not set up announcements")
+	view := NewView(logger, dispatcher)
+	switch cmd {
+	case "serve":
+		serveInBackground(logger, newAPIServer(view, gameState), *serveAddr)
+	case "dashboard":
+		server := newAPIServer(view, gameState)
+		server.dashboard = true
+		serveInBackground(logger, server, *dashboardAddr)
+	}
 	view.Start(gameState)
 }
+
+// serveInBackground serves the game over HTTP while the terminal runs. The
+// address is checked up front so a mistake is reported before the terminal
+// takes over the screen.
+func serveInBackground(logger *logrus.Logger, server *apiServer, addr string) {
+	app.FatalIfError(checkLoopback(addr), "Could not serve")
+	go func() {
+		logger.Infof("Serving the game on http://%v/", addr)
+		if err := server.ListenAndServe(addr); err != nil {
+			logger.Errorf("Game server stopped: %v", err)
+		}
+	}()
+}
+
+// openGame loads a saved game from a snapshot or journal file, or from the
+// newest snapshot in dir when latest is set. A dry run keeps its journal in
+// memory, so nothing is written to disk.
+func openGame(wd, file string, latest bool, dir string, dryRun bool) (*pandemic.Journal, *pandemic.GameState, error) {
+	if latest {
+		if dir == "" {
+			return nil, nil, fmt.Errorf("--latest needs a --dir to look in")
+		}
+		return loadLatestGame(filepath.Join(wd, dir), dryRun)
+	}
+	if file == "" {
+		return nil, nil, fmt.Errorf("Pass either --file or --latest --dir")
+	}
+	if filepath.Base(file) == pandemic.JournalFileName {
+		return continueJournal(filepath.Join(wd, file), dryRun)
+	}
+	gameState, err := pandemic.LoadGame(filepath.Join(wd, file))
+	if err != nil {
+		return nil, nil, err
+	}
+	return startJournal(gameState, dryRun)
+}
+
+// loadLatestGame continues the game saved in dir. A journal records every
+// command, so it is preferred when there is one; otherwise, or if the
+// journal can't be read, the newest snapshot that isn't corrupt is loaded.
+// An unreadable journal is kept alongside under another name, so that a
+// new one can be started from the snapshot.
+func loadLatestGame(dir string, dryRun bool) (*pandemic.Journal, *pandemic.GameState, error) {
+	journalPath := filepath.Join(dir, pandemic.JournalFileName)
+	var journalErr error
+	if _, err := os.Stat(journalPath); err == nil {
+		journal, gameState, err := continueJournal(journalPath, dryRun)
+		if err == nil {
+			return journal, gameState, nil
+		}
+		journalErr = err
+	}
+	gameState, _, err := pandemic.LatestGame(dir)
+	if err != nil {
+		if journalErr != nil {
+			return nil, nil, fmt.Errorf("Could not read %v (%v) and %v", journalPath, journalErr, err)
+		}
+		return nil, nil, err
+	}
+	if journalErr != nil && !dryRun {
+		unreadable := fmt.Sprintf("%v.unreadable-%v", journalPath, time.Now().UnixNano())
+		if err = os.Rename(journalPath, unreadable); err != nil {
+			return nil, nil, fmt.Errorf("Could not move aside unreadable journal %v: %v", journalPath, err)
+		}
+	}
+	return startJournal(gameState, dryRun)
+}
+
+func continueJournal(path string, dryRun bool) (*pandemic.Journal, *pandemic.GameState, error) {
+	if !dryRun {
+		return pandemic.OpenJournal(path)
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer in.Close()
+	return pandemic.ReadJournal(in, ioutil.Discard)
+}
+
+func startJournal(gameState *pandemic.GameState, dryRun bool) (*pandemic.Journal, *pandemic.GameState, error) {
+	var journal *pandemic.Journal
+	var err error
+	if dryRun {
+		journal, err = pandemic.NewJournal(gameState, ioutil.Discard)
+	} else {
+		journal, err = createJournal(gameState)
+	}
+	return journal, gameState, err
+}
+
+// Every game keeps its journal in a folder named after the game.
+func createJournal(gameState *pandemic.GameState) (*pandemic.Journal, error) {
+	err := os.MkdirAll(gameState.GameName, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("Could not create a game name folder: %v", err)
+	}
+	path := filepath.Join(gameState.GameName, pandemic.JournalFileName)
+	journal, err := pandemic.CreateJournal(path, gameState)
+	if err != nil {
+		return nil, fmt.Errorf("%v (load %v to continue an existing game)", err, path)
+	}
+	return journal, nil
+}
+
+// openHistory continues the commands typed for a game, kept next to its
+// journal so that they can be recalled after a restart.
+func openHistory(gameState *pandemic.GameState) (*console.History, error) {
+	err := os.MkdirAll(gameState.GameName, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("Could not create a game name folder: %v", err)
+	}
+	return console.OpenHistory(filepath.Join(gameState.GameName, console.HistoryFileName))
+}
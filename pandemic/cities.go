@@ -37,10 +37,12 @@ func (c CardName) Empty() bool {
 	return string(c) == ""
 }
 
+// A CityDeck owns every player card in the game. Drawn cards, turns and
+// player hands all point at the cards in All rather than holding copies.
 type CityDeck struct {
-	Drawn            []CityCard
-	All              []CityCard
-	StartCities      []CityCard
+	Drawn            []*CityCard
+	All              []*CityCard
+	StartCities      []*CityCard
 	ProbabilityModel *cityDeckProbabilityModel
 }
 
@@ -48,6 +50,9 @@ type CityCard struct {
 	CityName        CityName        `json:"city_name,omitempty"`
 	IsEpidemic      bool            `json:"is_epidemic"`
 	FundedEventName FundedEventName `json:"funded_event_name,omitempty"`
+	// Epidemics are numbered from 1 in the order they are drawn. Games
+	// saved before epidemics were numbered leave this at 0.
+	EpidemicNumber int `json:"epidemic_number,omitempty"`
 }
 
 type City struct {
@@ -58,6 +63,8 @@ type City struct {
 	Neighbors       []string    `json:"neighbors"`
 	NumInfections   int         `json:"num_infections"`
 	Quarantined     bool        `json:"quarantined"`
+	ResearchStation bool        `json:"research_station,omitempty"`
+	MilitaryBase    bool        `json:"military_base,omitempty"`
 }
 
 type Cities []*City
@@ -74,6 +81,9 @@ func (c CityCard) Name() CardName {
 	if c.IsFundedEvent() {
 		return CardName(c.FundedEventName)
 	}
+	if c.EpidemicNumber > 0 {
+		return CardName(fmt.Sprintf("epidemic-%v", c.EpidemicNumber))
+	}
 	return "epidemic"
 }
 
@@ -85,36 +95,37 @@ func (c CityCard) IsFundedEvent() bool {
 	return !c.FundedEventName.Empty()
 }
 
-// TODO: model city specializations / unfunded events
-// TODO: funded events + epidemics should be named for drawing. If the initial hands
-// contain funded events, all cure stats will be wrong.
-func (c Cities) GenerateCityDeck(epidemicCount int, events []*FundedEvent, startCities Set) (CityDeck, error) {
-	cards := []CityCard{}
+// GenerateCityDeck builds the deck with every city, the epidemics numbered
+// from 1, and each funded event. The start cards, which may be cities or
+// funded events, are dealt before any draws are counted.
+func (c Cities) GenerateCityDeck(epidemicCount int, events []*FundedEvent, startCards Set) (CityDeck, error) {
+	// TODO: model city specializations / unfunded events
+	cards := []*CityCard{}
 	for _, city := range c {
-		cards = append(cards, CityCard{city.Name, false, ""})
+		cards = append(cards, &CityCard{CityName: city.Name})
 	}
-	for i := 0; i < epidemicCount; i++ {
-		cards = append(cards, CityCard{"", true, ""})
+	for i := 1; i <= epidemicCount; i++ {
+		cards = append(cards, &CityCard{IsEpidemic: true, EpidemicNumber: i})
 	}
 	for _, event := range events {
-		cards = append(cards, CityCard{"", false, event.Name})
+		cards = append(cards, &CityCard{FundedEventName: event.Name})
 	}
 
-	probModel := generateProbabilityModel(len(cards)-startCities.Size(), epidemicCount)
+	probModel := generateProbabilityModel(len(cards)-startCards.Size(), epidemicCount)
 	deck := CityDeck{
-		Drawn:            []CityCard{},
+		Drawn:            []*CityCard{},
 		All:              cards,
 		ProbabilityModel: &probModel,
-		StartCities:      []CityCard{},
+		StartCities:      []*CityCard{},
 	}
-	for _, startCity := range startCities.Members() {
-		card, err := deck.GetCity(CityName(startCity))
-		if err != nil {
-			return deck, fmt.Errorf("%v is not a valid city name", startCity)
+	for _, startCard := range startCards.Members() {
+		card, err := deck.GetCard(CardName(startCard))
+		if err != nil || card.IsEpidemic {
+			return deck, fmt.Errorf("%v is not a valid start card", startCard)
 		}
 		// append directly to drawn without altering index.
-		deck.Drawn = append(deck.Drawn, *card)
-		deck.StartCities = append(deck.StartCities, *card)
+		deck.Drawn = append(deck.Drawn, card)
+		deck.StartCities = append(deck.StartCities, card)
 	}
 
 	return deck, nil
@@ -164,6 +175,8 @@ func (c Cities) CityNames() []CityName {
 	return names
 }
 
+// Infect adds a cube to the city. Returns true if the city was already at 3
+// cubes, in which case it outbreaks instead.
 func (c *City) Infect() bool {
 	if c.NumInfections == 3 {
 		return true
@@ -172,8 +185,12 @@ func (c *City) Infect() bool {
 	return false
 }
 
-func (c *City) Epidemic() {
+// Epidemic sets the city to 3 cubes. Returns true if the city already had
+// cubes, in which case the overflow causes an outbreak.
+func (c *City) Epidemic() bool {
+	outbreak := c.NumInfections > 0
 	c.NumInfections = 3
+	return outbreak
 }
 
 func (c *City) Quarantine() {
@@ -188,6 +205,19 @@ func (c *City) SetInfections(infections int) {
 	c.NumInfections = infections
 }
 
+func (c *City) CanBuild() bool {
+	return c.PanicLevel.CanBuildResearchStations()
+}
+
+func (c *City) IsNeighbor(cn CityName) bool {
+	for _, neighbor := range c.Neighbors {
+		if CityName(neighbor) == cn {
+			return true
+		}
+	}
+	return false
+}
+
 func (c CityDeck) Total() int {
 	return len(c.All)
 }
@@ -271,7 +301,7 @@ func (c *CityDeck) RemainingCardsWith(dt DiseaseType, cities *Cities) int {
 func (c *CityDeck) GetCard(cn CardName) (*CityCard, error) {
 	for _, card := range c.All {
 		if card.Name() == cn {
-			return &card, nil
+			return card, nil
 		}
 	}
 	return nil, fmt.Errorf("No card named %v in deck", cn)
@@ -283,12 +313,11 @@ func (c *CityDeck) GetCardByPrefix(prefix string) (*CityCard, error) {
 		if card.IsEpidemic {
 			continue
 		}
-		card := card
 		if strings.HasPrefix(strings.ToLower(string(card.Name())), strings.ToLower(prefix)) {
 			if ret != nil {
 				return nil, fmt.Errorf("'%v' is ambiguous", prefix)
 			}
-			ret = &card
+			ret = card
 		}
 	}
 	if ret == nil {
@@ -303,24 +332,19 @@ func (c *CityDeck) DrawCard(cn CardName) (*CityCard, error) {
 			return nil, fmt.Errorf("%v has already been drawn from the city deck", cn)
 		}
 	}
-	var target CityCard
-	for _, card := range c.All {
-		if card.Name() == cn {
-			target = card
-		}
-	}
-	if target.Name() == "" {
+	target, err := c.GetCard(cn)
+	if err != nil || target.IsEpidemic {
 		return nil, fmt.Errorf("No card called %v in the city deck", cn)
 	}
 	c.ProbabilityModel.DrawCity(c.probabilityIndex())
 	c.Drawn = append(c.Drawn, target)
-	return &target, nil
+	return target, nil
 }
 
 func (c *CityDeck) GetCity(cn CityName) (*CityCard, error) {
 	for _, card := range c.All {
 		if card.CityName == cn && card.IsCity() {
-			return &card, nil
+			return card, nil
 		}
 	}
 	return nil, fmt.Errorf("No city named %v in the deck", cn)
@@ -347,11 +371,89 @@ func (c *CityDeck) DrawEpidemic() error {
 	if drawnEpis >= totalEpis {
 		return fmt.Errorf("Already drawn %v epidemics this game, there shouldn't be any more", drawnEpis)
 	}
+	// epidemics can't be told apart when drawn, so take the next in order.
+	var next *CityCard
+	for _, card := range c.All {
+		if !card.IsEpidemic {
+			continue
+		}
+		if drawnEpis == 0 {
+			next = card
+			break
+		}
+		drawnEpis--
+	}
 	c.ProbabilityModel.DrawEpidemic(c.probabilityIndex())
-	c.Drawn = append(c.Drawn, CityCard{"", true, ""})
+	c.Drawn = append(c.Drawn, next)
 	return nil
 }
 
+// DrawIndex is the number of cards drawn from the city deck since the
+// starting hands were dealt.
+func (c CityDeck) DrawIndex() int {
+	return c.probabilityIndex()
+}
+
+// PossibleStriations lists the striation sizes of every way the deck could
+// have been built that is still consistent with the cards drawn so far.
+func (c CityDeck) PossibleStriations() [][]int {
+	striations := [][]int{}
+	for _, scenario := range c.ProbabilityModel.Scenarios {
+		striations = append(striations, scenario.CardCounts)
+	}
+	return striations
+}
+
+// RemainingNonEpidemics lists every card left in the deck other than the
+// epidemics.
+func (c CityDeck) RemainingNonEpidemics() []*CityCard {
+	drawn := Set{}
+	for _, card := range c.Drawn {
+		drawn.Add(card.Name())
+	}
+	remaining := []*CityCard{}
+	for _, card := range c.All {
+		if !card.IsEpidemic && !drawn.Contains(card.Name()) {
+			remaining = append(remaining, card)
+		}
+	}
+	return remaining
+}
+
+// relink points every drawn card back at the matching card in All, which
+// unmarshaling a saved deck leaves as separate copies. Drawn epidemics are
+// matched in order, since older saves did not number them.
+func (c *CityDeck) relink() {
+	epidemics := []*CityCard{}
+	for _, card := range c.All {
+		if card.IsEpidemic {
+			epidemics = append(epidemics, card)
+		}
+	}
+	for i, card := range c.Drawn {
+		if card.IsEpidemic && len(epidemics) > 0 {
+			c.Drawn[i] = epidemics[0]
+			epidemics = epidemics[1:]
+		} else {
+			c.Drawn[i] = c.canonical(card)
+		}
+	}
+	for i, card := range c.StartCities {
+		c.StartCities[i] = c.canonical(card)
+	}
+}
+
+// canonical finds the card in All with the same name as the given card.
+func (c *CityDeck) canonical(card *CityCard) *CityCard {
+	if card == nil {
+		return nil
+	}
+	if found, err := c.GetCard(card.Name()); err == nil {
+		return found
+	}
+	return card
+}
+
 func (c CityDeck) probabilityIndex() int {
 	return len(c.Drawn) - len(c.StartCities)
 }
@@ -408,12 +510,12 @@ type cityDeckScenario struct {
 }
 
 type EpidemicAnalysis struct {
-	FirstCardProbability       float64
-	SecondCardProbability      float64
-	SecondCardEpiAfterFirstEpi float64
-	PossibleScenarios          int
-	ScenariosWith100           int
-	ComingDrawsWith0           int
+	FirstCardProbability       float64 `json:"first_card_probability"`
+	SecondCardProbability      float64 `json:"second_card_probability"`
+	SecondCardEpiAfterFirstEpi float64 `json:"second_card_epi_after_first_epi"`
+	PossibleScenarios          int     `json:"possible_scenarios"`
+	ScenariosWith100           int     `json:"scenarios_with_100"`
+	ComingDrawsWith0           int     `json:"coming_draws_with_0"`
 }
 
 // 1 extra is 5 possible scenarios 5!/1!(4!) = 5
@@ -519,6 +621,27 @@ func (c *cityDeckProbabilityModel) EpidemicAnalysis(index int) EpidemicAnalysis
 	return analysis
 }
 
+// ExpectedEpidemicIndex is the draw index at which the given epidemic
+// (counting from 0) is expected to turn up, averaged over every scenario.
+// Each epidemic is equally likely to be anywhere in its striation.
+func (c *cityDeckProbabilityModel) ExpectedEpidemicIndex(epidemic int) float64 {
+	if len(c.Scenarios) == 0 {
+		return 0.0
+	}
+	var aggregate float64
+	for _, scenario := range c.Scenarios {
+		if epidemic >= len(scenario.CardCounts) {
+			return 0.0
+		}
+		start := 0
+		for _, count := range scenario.CardCounts[:epidemic] {
+			start += count
+		}
+		aggregate += float64(start) + float64(scenario.CardCounts[epidemic]-1)/2.0
+	}
+	return aggregate / float64(len(c.Scenarios))
+}
+
 func (c *cityDeckProbabilityModel) HighestIndex() int {
 	if len(c.Scenarios) == 0 {
 		return 0
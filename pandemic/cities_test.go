@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math"
+	"os"
 	"path/filepath"
 	"testing"
 )
@@ -44,3 +45,78 @@ func TestSimpleGame(t *testing.T) {
 		t.Fatalf("Expected 100%% chance of epidemic, got %v", prob)
 	}
 }
+
+func TestFundedEventInStartingHand(t *testing.T) {
+	data, err := ioutil.ReadFile("../data/new_game.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var settings NewGameSettings
+	if err = json.Unmarshal(data, &settings); err != nil {
+		t.Fatal(err)
+	}
+	settings.FundedEvents = []*FundedEvent{{Name: "airlift"}}
+	settings.Players[0].StartCards = []CardName{"chennai", "airlift"}
+	if data, err = json.Marshal(settings); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "funded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	newGameFile := filepath.Join(dir, "new_game.json")
+	if err = ioutil.WriteFile(newGameFile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	gs, err := NewGame(newGameFile, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	will, _ := gs.GameTurns.GetPlayer("Will")
+	airlift, err := gs.CityDeck.GetCard("airlift")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if will.Cards[1] != airlift {
+		t.Fatal("Expected Will's hand to hold the deck's airlift card")
+	}
+	// delhi is back in the deck now that Will starts with airlift instead
+	if remaining := gs.CityDeck.RemainingCardsWith(Black.Type, gs.Cities); remaining != 9 {
+		t.Fatalf("Expected 9 black cards left in the deck, got %v", remaining)
+	}
+	// Will holds chennai, so needs 3 of the 9 black cards among the 46 left
+	// in the deck, drawing 2 cards on each of their 5 usable turns:
+	// sum over k >= 3 of (9 choose k)(37 choose 10-k) / (46 choose 10)
+	if prob := gs.ProbabilityOfCuring(will, Black.Type); math.Abs(prob-0.2989190965336785) > 1e-9 {
+		t.Fatalf("Expected a 0.2989 probability of curing black, got %v", prob)
+	}
+
+	if err = gs.DrawCard("delhi"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = gs.Epidemic("lagos"); err != nil {
+		t.Fatal(err)
+	}
+	turn, _ := gs.GameTurns.CurrentTurn()
+	delhi, _ := gs.CityDeck.GetCard("delhi")
+	drawn := gs.CityDeck.Drawn
+	if turn.DrawnCards[0] != delhi || will.Cards[2] != delhi || drawn[len(drawn)-2] != delhi {
+		t.Fatal("Expected the turn, hand and deck to share the delhi card")
+	}
+	if name := drawn[len(drawn)-1].Name(); name != "epidemic-1" {
+		t.Fatalf("Expected the first epidemic to be epidemic-1, got %v", name)
+	}
+
+	clone, err := gs.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloneWill, _ := clone.GameTurns.GetPlayer("Will")
+	cloneTurn, _ := clone.GameTurns.CurrentTurn()
+	cloneAirlift, _ := clone.CityDeck.GetCard("airlift")
+	if cloneWill.Cards[1] != cloneAirlift || cloneWill.Cards[2] != cloneTurn.DrawnCards[0] {
+		t.Fatal("Expected a loaded game to share cards between hands, turns and the deck")
+	}
+}
@@ -0,0 +1,263 @@
+package console
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic"
+)
+
+// record applies the events a command makes. Several events are recorded
+// as one batch, so that a command naming several cards changes the game
+// all at once or not at all.
+func (d *Dispatcher) record(events []pandemic.Event) (pandemic.EventResult, error) {
+	if len(events) == 1 {
+		return d.journal.Record(d.game, events[0])
+	}
+	return d.journal.Record(d.game, pandemic.Event{Type: pandemic.BatchEvent, Events: events})
+}
+
+func (d *Dispatcher) infect(out io.Writer, args Args) error {
+	events := []pandemic.Event{}
+	cities := []string{}
+	for i := 0; i < args.Len(); i++ {
+		events = append(events, pandemic.Event{Type: pandemic.InfectEvent, City: args.City(i)})
+		cities = append(cities, args.City(i).String())
+	}
+	result, err := d.record(events)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Infected %v\n", strings.Join(cities, ", "))
+	d.printOutbreaks(out, result.Outbreaks)
+	return nil
+}
+
+func (d *Dispatcher) nextTurn(out io.Writer, args Args) error {
+	force := args.Has(0)
+	_, err := d.journal.Record(d.game, pandemic.Event{Type: pandemic.NextTurnEvent, Force: force})
+	if err != nil {
+		return fmt.Errorf("Could not move on to next turn: %v\nUse next-turn force to move on anyway", err)
+	}
+	turn, err := d.game.GameTurns.CurrentTurn()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "It is now %v's turn\n", turn.Player.HumanName)
+	message := turn.Player.HumanName
+	if turn.Player.Character != nil && turn.Player.Character.TurnMessage != "" {
+		message += " " + turn.Player.Character.TurnMessage
+	}
+	d.announce(out, TurnAnnouncement, message)
+	return nil
+}
+
+func (d *Dispatcher) giveCard(out io.Writer, args Args) error {
+	to, cardName := args.Player(0), args.Card(1)
+	from, err := d.game.GameTurns.CurrentTurn()
+	if err != nil {
+		return err
+	}
+	_, err = d.journal.Record(d.game, pandemic.Event{
+		Type:   pandemic.ExchangeCardEvent,
+		Card:   cardName,
+		Player: from.Player.HumanName,
+		To:     to.HumanName,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%v gave %v to %v\n", from.Player.HumanName, cardName, to.HumanName)
+	return nil
+}
+
+func (d *Dispatcher) epidemic(out io.Writer, args Args) error {
+	// with the infection deck used up there is no city to pull
+	var city pandemic.CityName
+	if args.Has(0) {
+		city = args.City(0)
+	}
+	result, err := d.journal.Record(d.game, pandemic.Event{Type: pandemic.EpidemicEvent, City: city})
+	if err != nil {
+		return err
+	}
+	if city.Empty() {
+		fmt.Fprintf(out, "Epidemic with no infection cards left. Infection rate is now %v\n", d.game.InfectionRate)
+		d.announce(out, EpidemicAnnouncement, "Epidemic")
+	} else {
+		fmt.Fprintf(out, "Epidemic in %v. Infection rate is now %v\n", city, d.game.InfectionRate)
+		d.announce(out, EpidemicAnnouncement, fmt.Sprintf("Epidemic in %v", city))
+	}
+	d.printOutbreaks(out, result.Outbreaks)
+	return nil
+}
+
+func (d *Dispatcher) infectRate(out io.Writer, args Args) error {
+	ir := args.Int(0)
+	_, err := d.journal.Record(d.game, pandemic.Event{Type: pandemic.InfectionRateEvent, Value: ir})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "infection rate now %v\n", ir)
+	return nil
+}
+
+func (d *Dispatcher) cityInfectLevel(out io.Writer, args Args) error {
+	cityName, il := args.City(0), args.Int(1)
+	_, err := d.journal.Record(d.game, pandemic.Event{Type: pandemic.InfectionLevelEvent, City: cityName, Value: il})
+	if err != nil {
+		return fmt.Errorf("Could not set infection level in %v: %v", cityName, err)
+	}
+	fmt.Fprintf(out, "Set infection level in %v to %v\n", cityName, il)
+	return nil
+}
+
+func (d *Dispatcher) cityDraw(out io.Writer, args Args) error {
+	curTurn, err := d.game.GameTurns.CurrentTurn()
+	if err != nil {
+		return err
+	}
+	events := []pandemic.Event{}
+	cards := []string{}
+	for i := 0; i < args.Len(); i++ {
+		events = append(events, pandemic.Event{Type: pandemic.DrawCardEvent, Card: args.Card(i)})
+		cards = append(cards, args.Card(i).String())
+	}
+	if _, err = d.record(events); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%v drew %v from city deck\n", curTurn.Player.HumanName, strings.Join(cards, ", "))
+	return nil
+}
+
+func (d *Dispatcher) quarantine(out io.Writer, args Args) error {
+	cityName := args.City(0)
+	_, err := d.journal.Record(d.game, pandemic.Event{Type: pandemic.QuarantineEvent, City: cityName})
+	if err != nil {
+		return fmt.Errorf("Could not quarantine %v: %v", cityName, err)
+	}
+	fmt.Fprintf(out, "Quarantined %v\n", cityName)
+	return nil
+}
+
+func (d *Dispatcher) discard(out io.Writer, args Args) error {
+	curTurn, err := d.game.GameTurns.CurrentTurn()
+	if err != nil {
+		return err
+	}
+	// whoever is over the hand limit has to discard first
+	discarder := curTurn.Player.HumanName
+	if over := d.overHandLimit(); over != nil {
+		discarder = over.HumanName
+	}
+	events := []pandemic.Event{}
+	cards := []string{}
+	for i := 0; i < args.Len(); i++ {
+		events = append(events, pandemic.Event{Type: pandemic.DiscardEvent, Card: args.Card(i), Player: discarder})
+		cards = append(cards, args.Card(i).String())
+	}
+	if _, err = d.record(events); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%v discarded %v\n", discarder, strings.Join(cards, ", "))
+	return nil
+}
+
+func (d *Dispatcher) playEvent(out io.Writer, args Args) error {
+	cardName := args.Card(0)
+	holder := d.game.CardHolder(cardName)
+	if holder == nil {
+		return fmt.Errorf("Nobody is holding %v", cardName)
+	}
+	_, err := d.journal.Record(d.game, pandemic.Event{Type: pandemic.PlayEventEvent, Card: cardName, Player: holder.HumanName})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%v played %v, which is now removed from the game\n", holder.HumanName, cardName)
+	return nil
+}
+
+func (d *Dispatcher) removeQuarantine(out io.Writer, args Args) error {
+	cityName := args.City(0)
+	_, err := d.journal.Record(d.game, pandemic.Event{Type: pandemic.RemoveQuarantineEvent, City: cityName})
+	if err != nil {
+		return fmt.Errorf("Could not remove quarantine from %v: %v", cityName, err)
+	}
+	fmt.Fprintf(out, "Removed quarantine from %v\n", cityName)
+	return nil
+}
+
+func (d *Dispatcher) cure(out io.Writer, args Args) error {
+	disease := args.Disease(0)
+	curTurn, err := d.game.GameTurns.CurrentTurn()
+	if err != nil {
+		return err
+	}
+	_, err = d.journal.Record(d.game, pandemic.Event{Type: pandemic.CureEvent, Disease: disease, Player: curTurn.Player.HumanName})
+	if err != nil {
+		return fmt.Errorf("Could not cure %v: %v", disease, err)
+	}
+	fmt.Fprintf(out, "%v cured %v\n", curTurn.Player.HumanName, disease)
+	if d.game.IsEradicated(disease) {
+		fmt.Fprintln(out, d.Style.AllGood("%v is eradicated", disease))
+	}
+	return nil
+}
+
+// moveWith makes a command that moves a player with the given kind of
+// movement.
+func moveWith(eventType pandemic.EventType) func(d *Dispatcher, out io.Writer, args Args) error {
+	return func(d *Dispatcher, out io.Writer, args Args) error {
+		player, cityName := args.Player(0), args.City(1)
+		from := player.Location
+		_, err := d.journal.Record(d.game, pandemic.Event{Type: eventType, Player: player.HumanName, City: cityName})
+		if err != nil {
+			return fmt.Errorf("Could not move %v to %v: %v", player.HumanName, cityName, err)
+		}
+		if from.Empty() {
+			fmt.Fprintf(out, "%v is now in %v\n", player.HumanName, cityName)
+		} else {
+			fmt.Fprintf(out, "%v moved from %v to %v\n", player.HumanName, from, cityName)
+		}
+		return nil
+	}
+}
+
+// buildWith makes a command that builds the given kind of building.
+func buildWith(eventType pandemic.EventType, building string) func(d *Dispatcher, out io.Writer, args Args) error {
+	return func(d *Dispatcher, out io.Writer, args Args) error {
+		cityName := args.City(0)
+		_, err := d.journal.Record(d.game, pandemic.Event{Type: eventType, City: cityName})
+		if err != nil {
+			return fmt.Errorf("Could not build a %v in %v: %v", building, cityName, err)
+		}
+		fmt.Fprintf(out, "Built a %v in %v\n", building, cityName)
+		return nil
+	}
+}
+
+// again only runs when there is nothing to repeat; otherwise Execute runs
+// the last command in its place.
+func (d *Dispatcher) again(out io.Writer, args Args) error {
+	return fmt.Errorf("No command to repeat")
+}
+
+func (d *Dispatcher) undo(out io.Writer, args Args) error {
+	e, err := d.journal.Undo(d.game)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Undid %v\n", e)
+	return nil
+}
+
+func (d *Dispatcher) redo(out io.Writer, args Args) error {
+	e, result, err := d.journal.Redo(d.game)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Redid %v\n", e)
+	d.printOutbreaks(out, result.Outbreaks)
+	return nil
+}
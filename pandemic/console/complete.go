@@ -0,0 +1,79 @@
+package console
+
+import (
+	"sort"
+	"strings"
+)
+
+// Complete lists the ways the last of the words typed so far could be
+// finished, based on what the command expects in that position. The first
+// word completes to a command name; later words complete to cities, cards,
+// players and so on.
+func (d *Dispatcher) Complete(words []string) []string {
+	if len(words) == 0 {
+		return []string{}
+	}
+	prefix := words[len(words)-1]
+	if len(words) == 1 {
+		return matching(commandNames(), prefix)
+	}
+	command, ok := LookupCommand(words[0])
+	if !ok {
+		return []string{}
+	}
+	arg, ok := command.argAt(len(words) - 2)
+	if !ok {
+		return []string{}
+	}
+	return matching(d.candidates(arg), prefix)
+}
+
+// candidates lists every value an argument could take.
+func (d *Dispatcher) candidates(arg Arg) []string {
+	candidates := []string{}
+	switch arg.Type {
+	case CityArg, CardArg:
+		for _, card := range d.game.CityDeck.All {
+			if card.IsCity() || (arg.Type == CardArg && card.IsFundedEvent()) {
+				candidates = append(candidates, string(card.Name()))
+			}
+		}
+	case PlayerArg:
+		for _, player := range d.game.GameTurns.PlayerOrder {
+			candidates = append(candidates, player.HumanName)
+		}
+	case DiseaseArg:
+		for _, data := range d.game.DiseaseData {
+			candidates = append(candidates, strings.ToLower(data.Type.String()))
+		}
+	case CommandArg:
+		candidates = commandNames()
+	case WordArg:
+		candidates = arg.Choices
+	}
+	return candidates
+}
+
+func commandNames() []string {
+	names := []string{}
+	for _, command := range commands {
+		names = append(names, command.Name)
+	}
+	return names
+}
+
+// matching keeps the candidates that start with prefix, ignoring case, in
+// alphabetical order.
+func matching(candidates []string, prefix string) []string {
+	matches := []string{}
+	seen := map[string]bool{}
+	for _, candidate := range candidates {
+		if seen[candidate] || !strings.HasPrefix(strings.ToLower(candidate), strings.ToLower(prefix)) {
+			continue
+		}
+		seen[candidate] = true
+		matches = append(matches, candidate)
+	}
+	sort.Strings(matches)
+	return matches
+}
@@ -0,0 +1,54 @@
+package console
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic"
+)
+
+func TestComplete(t *testing.T) {
+	d, gs := newTestDispatcher(t)
+	player := gs.GameTurns.PlayerOrder[1].HumanName
+
+	cases := []struct {
+		words    []string
+		expected []string
+	}{
+		{[]string{"rem"}, []string{"remove-quarantine"}},
+		{[]string{"infect", "la"}, []string{"lagos"}},
+		{[]string{"i", "la"}, []string{"lagos"}},
+		{[]string{"give-card", player[:2]}, []string{player}},
+		{[]string{"next-turn", ""}, []string{"force"}},
+		{[]string{"cure", "bl"}, []string{"black", "blue"}},
+		{[]string{"help", "shut"}, []string{"shuttle"}},
+		{[]string{"infect", "lagos", "kh"}, []string{"khartoum"}},
+		{[]string{"quarantine", "lagos", ""}, []string{}},
+		{[]string{"infect-rate", ""}, []string{}},
+		{[]string{"fly", "la"}, []string{}},
+	}
+	for _, c := range cases {
+		completions := d.Complete(c.words)
+		if !reflect.DeepEqual(completions, c.expected) {
+			t.Errorf("Expected %v to complete to %v, got %v", c.words, c.expected, completions)
+		}
+	}
+}
+
+func TestCompleteCardsIncludeFundedEvents(t *testing.T) {
+	d, gs := newTestDispatcher(t)
+	event := "airlift"
+	gs.CityDeck.All = append(gs.CityDeck.All, &pandemic.CityCard{FundedEventName: pandemic.FundedEventName(event)})
+	found := false
+	for _, completion := range d.Complete([]string{"play-event", event[:3]}) {
+		found = found || completion == event
+	}
+	if !found {
+		t.Fatalf("Expected %v to be offered for play-event", event)
+	}
+	for _, completion := range d.Complete([]string{"infect", event[:3]}) {
+		if completion == event {
+			t.Fatalf("Expected infect not to offer the funded event %v", event)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+// Package console parses and runs the commands typed into the game's
+// console, so the terminal, the HTTP API and scripts all share them.
+package console
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic"
+)
+
+// Style colors the output of commands. The terminal uses colors, while
+// scripts and the HTTP API use PlainStyle.
+type Style struct {
+	AllGood   func(string, ...interface{}) string
+	Warning   func(string, ...interface{}) string
+	Highlight func(string, ...interface{}) string
+	OhFuck    func(string, ...interface{}) string
+}
+
+var PlainStyle = Style{fmt.Sprintf, fmt.Sprintf, fmt.Sprintf, fmt.Sprintf}
+
+// Things that happen in the game that are worth reading out to the table.
+const (
+	TurnAnnouncement               = "turn"
+	EpidemicAnnouncement           = "epidemic"
+	OutbreakAnnouncement           = "outbreak"
+	GuaranteedEpidemicAnnouncement = "guaranteed-epidemic"
+)
+
+var AnnouncementEvents = []string{
+	TurnAnnouncement,
+	EpidemicAnnouncement,
+	OutbreakAnnouncement,
+	GuaranteedEpidemicAnnouncement,
+}
+
+// An Announcer is told about each event as it happens, and decides for
+// itself which ones to pass on.
+type Announcer interface {
+	Announce(event string, message string) error
+}
+
+// A Dispatcher runs console commands against a game, recording each change
+// in the game's journal. It does no locking of its own; callers sharing the
+// game between goroutines must hold their lock around Execute.
+type Dispatcher struct {
+	game    *pandemic.GameState
+	journal *pandemic.Journal
+
+	Style     Style
+	Announcer Announcer
+	History   *History
+
+	// mustDiscard is the player pushed over the hand limit by a command
+	// run here. Saves from before the limit was enforced can hold bigger
+	// hands, so only players who went over this session are held to it.
+	mustDiscard string
+}
+
+func NewDispatcher(game *pandemic.GameState, journal *pandemic.Journal) *Dispatcher {
+	return &Dispatcher{
+		game:    game,
+		journal: journal,
+		Style:   PlainStyle,
+		History: NewHistory(ioutil.Discard),
+	}
+}
+
+// Execute runs a single command, writing what happened to out. A command
+// that can't be carried out returns an error and leaves the game as it was.
+func (d *Dispatcher) Execute(out io.Writer, commandBuffer string) error {
+	commandBuffer = strings.Trim(commandBuffer, "\n\t\r ")
+	if commandBuffer == "" {
+		return nil
+	}
+	commandArgs := strings.Fields(commandBuffer)
+	command, ok := LookupCommand(commandArgs[0])
+	if ok && command.Name == "again" && len(commandArgs) == 1 {
+		if last, ok := d.History.Last(); ok {
+			fmt.Fprintln(out, d.Style.Highlight("again: %v", last))
+			return d.Execute(out, last)
+		}
+	} else if err := d.History.Add(commandBuffer); err != nil {
+		fmt.Fprintln(out, d.Style.Warning("%v", err))
+	}
+	if !ok {
+		return fmt.Errorf("Unrecognized command %v. Type help to list the commands.", commandArgs[0])
+	}
+
+	if over := d.overHandLimit(); over != nil && !command.OverHandLimit {
+		return fmt.Errorf("%v has %v cards, over the hand limit of %v. Use discard <card> or play-event <event> before going on.", over.HumanName, len(over.Cards), pandemic.HandLimit)
+	}
+	args, err := d.parseArgs(command, commandArgs[1:])
+	if err != nil {
+		return err
+	}
+	before := d.game.CityDeck.EpidemicAnalysis()
+	hands := d.handSizes()
+	if err = command.run(d, out, args); err != nil {
+		return err
+	}
+
+	d.announceGuaranteedEpidemic(out, before)
+	d.promptHandLimit(out, hands)
+	return nil
+}
+
+func (d *Dispatcher) handSizes() map[string]int {
+	hands := map[string]int{}
+	for _, player := range d.game.GameTurns.PlayerOrder {
+		hands[player.HumanName] = len(player.Cards)
+	}
+	return hands
+}
+
+// overHandLimit returns the player who has to discard before the game can
+// go on, if there is one.
+func (d *Dispatcher) overHandLimit() *pandemic.Player {
+	if d.mustDiscard == "" {
+		return nil
+	}
+	player, err := d.game.GameTurns.GetPlayer(d.mustDiscard)
+	if err != nil || !player.OverHandLimit() {
+		d.mustDiscard = ""
+		return nil
+	}
+	return player
+}
+
+// promptHandLimit asks whoever a command pushed over the hand limit to
+// discard, given the sizes of the hands before the command.
+func (d *Dispatcher) promptHandLimit(out io.Writer, before map[string]int) {
+	for _, player := range d.game.GameTurns.PlayerOrder {
+		if player.OverHandLimit() && len(player.Cards) > before[player.HumanName] {
+			d.mustDiscard = player.HumanName
+		}
+	}
+	over := d.overHandLimit()
+	if over == nil {
+		return
+	}
+	fmt.Fprintln(out, d.Style.OhFuck("%v has %v cards, over the hand limit of %v.", over.HumanName, len(over.Cards), pandemic.HandLimit))
+	fmt.Fprintln(out, d.Style.Warning("Use discard <card> or play-event <event> before going on."))
+}
+
+func (d *Dispatcher) printOutbreaks(out io.Writer, outbreaks []pandemic.CityName) {
+	if len(outbreaks) == 0 {
+		return
+	}
+	chain := make([]string, len(outbreaks))
+	for i, city := range outbreaks {
+		chain[i] = city.String()
+	}
+	fmt.Fprintln(out, d.Style.OhFuck("Outbreak! %v", strings.Join(chain, " -> ")))
+	fmt.Fprintf(out, "%v outbreaks so far this game\n", d.game.Outbreaks)
+	d.announce(out, OutbreakAnnouncement, fmt.Sprintf("Outbreak in %v", strings.Join(chain, ", then ")))
+}
+
+// announce passes an event on to the announcer. Failing to announce isn't
+// a reason to fail the command, so problems are only reported.
+func (d *Dispatcher) announce(out io.Writer, event string, message string) {
+	if d.Announcer == nil {
+		return
+	}
+	if err := d.Announcer.Announce(event, message); err != nil {
+		fmt.Fprintln(out, d.Style.Warning("%v", err))
+	}
+}
+
+// announceGuaranteedEpidemic warns the table when a command leaves the city
+// deck in a state where some striation scenarios guarantee an epidemic.
+func (d *Dispatcher) announceGuaranteedEpidemic(out io.Writer, before pandemic.EpidemicAnalysis) {
+	after := d.game.CityDeck.EpidemicAnalysis()
+	if after.ScenariosWith100 > 0 && before.ScenariosWith100 == 0 {
+		d.announce(out, GuaranteedEpidemicAnnouncement, fmt.Sprintf("Careful, %v of %v scenarios guarantee an epidemic", after.ScenariosWith100, after.PossibleScenarios))
+	}
+}
+
+func (d *Dispatcher) getCardByPrefix(entry string) (pandemic.CardName, error) {
+	card, err := d.game.CityDeck.GetCardByPrefix(entry)
+	if err != nil {
+		return "", err
+	}
+	return card.Name(), nil
+}
+
+func (d *Dispatcher) getCityByPrefix(entry string) (pandemic.CityName, error) {
+	card, err := d.game.CityDeck.GetCardByPrefix(entry)
+	if err != nil {
+		return pandemic.CityName(""), err
+	}
+	if !card.IsCity() {
+		return pandemic.CityName(""), fmt.Errorf("%v is not a city", card.Name())
+	}
+	return card.CityName, nil
+}
+
+func (d *Dispatcher) getPlayerByPrefix(entry string) (*pandemic.Player, error) {
+	var ret *pandemic.Player
+	for _, player := range d.game.GameTurns.PlayerOrder {
+		if strings.HasPrefix(strings.ToLower(player.HumanName), strings.ToLower(entry)) {
+			if ret != nil {
+				return nil, fmt.Errorf("%v is an ambiguous human name", entry)
+			}
+			ret = player
+		}
+	}
+	if ret == nil {
+		return nil, fmt.Errorf("%v is not a prefix for any player", entry)
+	}
+	return ret, nil
+}
+
+func (d *Dispatcher) getDiseaseByPrefix(entry string) (pandemic.DiseaseType, error) {
+	var ret pandemic.DiseaseType
+	for _, data := range d.game.DiseaseData {
+		if strings.HasPrefix(strings.ToLower(data.Type.String()), strings.ToLower(entry)) {
+			if ret != "" {
+				return "", fmt.Errorf("%v is an ambiguous disease", entry)
+			}
+			ret = data.Type
+		}
+	}
+	if ret == "" {
+		return "", fmt.Errorf("%v is not a prefix for any disease", entry)
+	}
+	return ret, nil
+}
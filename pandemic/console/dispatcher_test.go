@@ -0,0 +1,226 @@
+package console
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic"
+)
+
+type recordingAnnouncer struct {
+	events []string
+}
+
+func (r *recordingAnnouncer) Announce(event string, message string) error {
+	r.events = append(r.events, event+": "+message)
+	return nil
+}
+
+func newTestDispatcher(t *testing.T) (*Dispatcher, *pandemic.GameState) {
+	gs, err := pandemic.NewGame("../../data/new_game.json", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal, err := pandemic.NewJournal(gs, ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewDispatcher(gs, journal), gs
+}
+
+func execute(t *testing.T, d *Dispatcher, command string) string {
+	var out bytes.Buffer
+	if err := d.Execute(&out, command); err != nil {
+		t.Fatalf("%v: %v", command, err)
+	}
+	return out.String()
+}
+
+func TestExecuteCommands(t *testing.T) {
+	d, gs := newTestDispatcher(t)
+
+	if out := execute(t, d, "i lagos"); out != "Infected lagos\n" {
+		t.Fatalf("Unexpected output from infect: %q", out)
+	}
+	if !gs.InfectionDeck.Drawn.Contains(pandemic.CityName("lagos")) {
+		t.Fatal("Expected lagos to be drawn from the infection deck")
+	}
+	execute(t, d, "undo")
+	if gs.InfectionDeck.Drawn.Contains(pandemic.CityName("lagos")) {
+		t.Fatal("Expected undo to put lagos back")
+	}
+	if out := execute(t, d, "  "); out != "" {
+		t.Fatalf("Expected a blank command to do nothing, got %q", out)
+	}
+}
+
+func TestExecuteRejectsBadCommands(t *testing.T) {
+	d, _ := newTestDispatcher(t)
+
+	for _, command := range []string{"fly-away", "infect", "remove-quarantine", "infect-rate two", "give-card nobody lagos"} {
+		var out bytes.Buffer
+		if err := d.Execute(&out, command); err == nil {
+			t.Errorf("Expected %q to fail", command)
+		}
+		if out.Len() != 0 {
+			t.Errorf("Expected %q to write nothing, got %q", command, out.String())
+		}
+	}
+}
+
+func TestExecuteAnnounces(t *testing.T) {
+	d, _ := newTestDispatcher(t)
+	announcer := &recordingAnnouncer{}
+	d.Announcer = announcer
+
+	execute(t, d, "next-turn force")
+	if len(announcer.events) != 1 || !strings.HasPrefix(announcer.events[0], TurnAnnouncement+": ") {
+		t.Fatalf("Expected the next turn to be announced, got %v", announcer.events)
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	d, _ := newTestDispatcher(t)
+
+	var out bytes.Buffer
+	err := d.Execute(&out, "give-card lagos")
+	if err == nil || err.Error() != "Usage: give-card <player> <card>" {
+		t.Fatalf("Expected a usage error, got %v", err)
+	}
+	err = d.Execute(&out, "next-turn now")
+	if err == nil || !strings.Contains(err.Error(), "usage: next-turn [force]") {
+		t.Fatalf("Expected a usage error for a bad word, got %v", err)
+	}
+}
+
+func TestHelp(t *testing.T) {
+	d, _ := newTestDispatcher(t)
+
+	out := execute(t, d, "help")
+	for _, command := range Commands() {
+		if !strings.Contains(out, command.Usage()) {
+			t.Errorf("Expected help to list %v", command.Name)
+		}
+	}
+	out = execute(t, d, "help rq")
+	if !strings.HasPrefix(out, "Usage: remove-quarantine <city>\n") {
+		t.Fatalf("Unexpected help for remove-quarantine: %q", out)
+	}
+}
+
+func TestExecuteBatch(t *testing.T) {
+	d, gs := newTestDispatcher(t)
+	drawn := func(city string) bool {
+		return gs.InfectionDeck.Drawn.Contains(pandemic.CityName(city))
+	}
+
+	if out := execute(t, d, "i lagos khar essen"); out != "Infected lagos, khartoum, essen\n" {
+		t.Fatalf("Unexpected output from infect: %q", out)
+	}
+	if !drawn("lagos") || !drawn("khartoum") || !drawn("essen") {
+		t.Fatal("Expected every city to be drawn from the infection deck")
+	}
+	execute(t, d, "undo")
+	if drawn("lagos") || drawn("khartoum") || drawn("essen") {
+		t.Fatal("Expected undo to put every city back")
+	}
+
+	for _, command := range []string{"i lagos nowhere", "i lagos lagos"} {
+		var out bytes.Buffer
+		if err := d.Execute(&out, command); err == nil {
+			t.Fatalf("Expected %q to fail", command)
+		}
+		if drawn("lagos") || out.Len() != 0 {
+			t.Fatalf("Expected %q to leave the game as it was", command)
+		}
+	}
+}
+
+func TestExecuteOnGameWithoutPlayers(t *testing.T) {
+	gs, err := pandemic.LoadGame("../../may/game_1471404516098025204_i.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal, err := pandemic.NewJournal(gs, ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDispatcher(gs, journal)
+	if out := execute(t, d, "quarantine lagos"); out != "Quarantined lagos\n" {
+		t.Fatalf("Unexpected output from quarantine: %q", out)
+	}
+	var out bytes.Buffer
+	if err = d.Execute(&out, "next-turn force"); err == nil {
+		t.Fatal("Expected a game with no players to have no turns to move on to")
+	}
+}
+
+func TestHandLimitHoldsUpTheGame(t *testing.T) {
+	d, gs := newTestDispatcher(t)
+
+	// the second player is handed everyone's cards, the last two by the
+	// first player during their turn
+	to := gs.GameTurns.PlayerOrder[1]
+	for _, player := range gs.GameTurns.PlayerOrder[2:] {
+		for _, card := range player.StartCards {
+			if err := gs.ExchangeCard(player, to, card); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for i, card := range gs.GameTurns.PlayerOrder[0].StartCards {
+		execute(t, d, fmt.Sprintf("give-card %v %v", to.HumanName, card))
+		if i == 0 && len(to.Cards) != pandemic.HandLimit {
+			t.Fatalf("Expected %v to be at the hand limit, has %v cards", to.HumanName, len(to.Cards))
+		}
+	}
+	var out bytes.Buffer
+	if err := d.Execute(&out, "infect lagos"); err == nil || !strings.Contains(err.Error(), "over the hand limit") {
+		t.Fatalf("Expected %v to have to discard first, got %v", to.HumanName, err)
+	}
+	if out := execute(t, d, "discard "+string(to.Cards[0].Name())); !strings.HasPrefix(out, to.HumanName+" discarded") {
+		t.Fatalf("Expected %v to discard, got %q", to.HumanName, out)
+	}
+	execute(t, d, "infect lagos")
+}
+
+func TestHandLimitNotHeldAgainstOldSaves(t *testing.T) {
+	gs, err := pandemic.LoadGame("../../aug/game_1476938281532306965_c.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gs.PlayerOverHandLimit() == nil {
+		t.Fatal("Expected this save to have a player over the hand limit")
+	}
+	journal, err := pandemic.NewJournal(gs, ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDispatcher(gs, journal)
+	city := gs.InfectionDeck.TopStriation().Members()[0]
+	if out := execute(t, d, "infect "+city); out != fmt.Sprintf("Infected %v\n", city) {
+		t.Fatalf("Unexpected output from infect: %q", out)
+	}
+}
+
+func TestEpidemicWithoutCity(t *testing.T) {
+	d, gs := newTestDispatcher(t)
+
+	var out bytes.Buffer
+	if err := d.Execute(&out, "epidemic"); err == nil || !strings.Contains(err.Error(), "bottom of the infection deck") {
+		t.Fatalf("Expected an epidemic to need a city while there are infection cards, got %v", err)
+	}
+	execute(t, d, "infect "+strings.Join(gs.InfectionDeck.TopStriation().Members(), " "))
+	if len(gs.InfectionDeck.Striations) != 0 {
+		t.Fatal("Expected every infection card to be drawn")
+	}
+	if out := execute(t, d, "epidemic"); !strings.HasPrefix(out, "Epidemic with no infection cards left") {
+		t.Fatalf("Unexpected output from epidemic: %q", out)
+	}
+	if gs.CityDeck.EpidemicsDrawn() != 1 || gs.InfectionDeck.Drawn.Size() != 0 {
+		t.Fatal("Expected the epidemic to be drawn and the infection discard pile shuffled back")
+	}
+}
@@ -0,0 +1,94 @@
+package console
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+)
+
+const HistoryFileName = "history.txt"
+
+// A History remembers the commands typed into the console, one per line, so
+// they can be recalled and repeated. New commands are appended to out.
+type History struct {
+	lines []string
+	out   io.Writer
+}
+
+func NewHistory(out io.Writer) *History {
+	return &History{lines: []string{}, out: out}
+}
+
+// ReadHistory reads back the commands in in. Further commands are appended
+// to out.
+func ReadHistory(in io.Reader, out io.Writer) (*History, error) {
+	h := NewHistory(out)
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.lines = append(h.lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Could not read command history: %v", err)
+	}
+	return h, nil
+}
+
+// OpenHistory reads the history file at path, creating it if needed, and
+// continues appending to it.
+func OpenHistory(path string) (*History, error) {
+	out, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("Could not open command history: %v", err)
+	}
+	h, err := ReadHistory(out, out)
+	if err != nil {
+		out.Close()
+		return nil, err
+	}
+	return h, nil
+}
+
+// Add remembers a command. A command that repeats the one before it is
+// only remembered once.
+func (h *History) Add(line string) error {
+	if last, ok := h.Last(); ok && last == line {
+		return nil
+	}
+	h.lines = append(h.lines, line)
+	_, err := fmt.Fprintln(h.out, line)
+	if err != nil {
+		return fmt.Errorf("Could not save command history: %v", err)
+	}
+	return nil
+}
+
+// Last returns the most recent command, if there is one.
+func (h *History) Last() (string, bool) {
+	if len(h.lines) == 0 {
+		return "", false
+	}
+	return h.lines[len(h.lines)-1], true
+}
+
+// Back returns the command typed n commands ago, counting the most recent
+// command as 1.
+func (h *History) Back(n int) (string, bool) {
+	if n < 1 || n > len(h.lines) {
+		return "", false
+	}
+	return h.lines[len(h.lines)-n], true
+}
+
+func (h *History) Len() int {
+	return len(h.lines)
+}
+
+func (h *History) Close() error {
+	if closer, ok := h.out.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package console
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHistoryRoundTrip(t *testing.T) {
+	var out bytes.Buffer
+	history := NewHistory(&out)
+	for _, line := range []string{"infect lagos", "infect lagos", "next-turn"} {
+		if err := history.Add(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if history.Len() != 2 {
+		t.Fatalf("Expected a repeated command to be remembered once, got %v commands", history.Len())
+	}
+
+	read, err := ReadHistory(strings.NewReader(out.String()), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last, _ := read.Last(); last != "next-turn" {
+		t.Fatalf("Expected next-turn to be the last command, got %q", last)
+	}
+	if first, _ := read.Back(2); first != "infect lagos" {
+		t.Fatalf("Expected infect lagos two commands back, got %q", first)
+	}
+	if _, ok := read.Back(3); ok {
+		t.Fatal("Expected nothing three commands back")
+	}
+}
+
+func TestAgain(t *testing.T) {
+	d, gs := newTestDispatcher(t)
+
+	var out bytes.Buffer
+	if err := d.Execute(&out, "again"); err == nil {
+		t.Fatal("Expected again to fail with no command to repeat")
+	}
+	execute(t, d, "next-turn force")
+	if out := execute(t, d, "!!"); !strings.Contains(out, "again: next-turn force") {
+		t.Fatalf("Expected !! to show the command it repeats, got %q", out)
+	}
+	turn, err := gs.GameTurns.CurrentTurn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if turn.Player != gs.GameTurns.PlayerOrder[2] {
+		t.Fatalf("Expected again to move on another turn, but it is %v's turn", turn.Player.HumanName)
+	}
+	if last, _ := d.History.Last(); last != "next-turn force" {
+		t.Fatalf("Expected again not to be remembered itself, got %q", last)
+	}
+}
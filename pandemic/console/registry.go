@@ -0,0 +1,364 @@
+package console
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic"
+)
+
+// An ArgType says what an argument names, and so how a prefix typed for it
+// is resolved.
+type ArgType string
+
+const (
+	CityArg    = ArgType("city")
+	CardArg    = ArgType("card")
+	PlayerArg  = ArgType("player")
+	IntArg     = ArgType("number")
+	DiseaseArg = ArgType("disease")
+	CommandArg = ArgType("command")
+	// A WordArg must be one of its Choices, spelled out in full.
+	WordArg = ArgType("word")
+)
+
+type Arg struct {
+	Name     string
+	Type     ArgType
+	Choices  []string
+	Optional bool
+	// A Repeated argument is typed one or more times. Only the last
+	// argument of a command can repeat.
+	Repeated bool
+}
+
+func (a Arg) String() string {
+	if a.Optional {
+		return fmt.Sprintf("[%v]", a.Name)
+	}
+	if a.Repeated {
+		return fmt.Sprintf("<%v>...", a.Name)
+	}
+	return fmt.Sprintf("<%v>", a.Name)
+}
+
+// A Command is one of the commands the console accepts.
+type Command struct {
+	Name    string
+	Aliases []string
+	Args    []Arg
+	Help    string
+
+	// While a player is over the hand limit, only commands that can bring
+	// them back under it are accepted.
+	OverHandLimit bool
+
+	run func(d *Dispatcher, out io.Writer, args Args) error
+}
+
+// Usage describes how to call the command, such as
+// "give-card <player> <card>".
+func (c *Command) Usage() string {
+	usage := []string{c.Name}
+	for _, arg := range c.Args {
+		usage = append(usage, arg.String())
+	}
+	return strings.Join(usage, " ")
+}
+
+func (c *Command) names() []string {
+	return append([]string{c.Name}, c.Aliases...)
+}
+
+func (c *Command) repeats() bool {
+	return len(c.Args) > 0 && c.Args[len(c.Args)-1].Repeated
+}
+
+// argAt finds the argument typed in position i, counting from 0.
+func (c *Command) argAt(i int) (Arg, bool) {
+	if i < len(c.Args) {
+		return c.Args[i], true
+	}
+	if c.repeats() {
+		return c.Args[len(c.Args)-1], true
+	}
+	return Arg{}, false
+}
+
+// Args holds a command's arguments once they have been resolved to the
+// cities, cards, players and so on that they name.
+type Args struct {
+	values []interface{}
+}
+
+func (a Args) Len() int                           { return len(a.values) }
+func (a Args) Has(i int) bool                     { return i < len(a.values) }
+func (a Args) City(i int) pandemic.CityName       { return a.values[i].(pandemic.CityName) }
+func (a Args) Card(i int) pandemic.CardName       { return a.values[i].(pandemic.CardName) }
+func (a Args) Player(i int) *pandemic.Player      { return a.values[i].(*pandemic.Player) }
+func (a Args) Int(i int) int                      { return a.values[i].(int) }
+func (a Args) Disease(i int) pandemic.DiseaseType { return a.values[i].(pandemic.DiseaseType) }
+func (a Args) Word(i int) string                  { return a.values[i].(string) }
+func (a Args) Command(i int) *Command             { return a.values[i].(*Command) }
+
+// Commands lists every command the console accepts, in the order help
+// shows them.
+func Commands() []*Command {
+	return commands
+}
+
+// LookupCommand finds a command by its name or one of its aliases.
+func LookupCommand(name string) (*Command, bool) {
+	for _, command := range commands {
+		for _, n := range command.names() {
+			if n == name {
+				return command, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// parseArgs resolves each argument typed for the command.
+func (d *Dispatcher) parseArgs(command *Command, typed []string) (Args, error) {
+	required := 0
+	for _, arg := range command.Args {
+		if !arg.Optional {
+			required++
+		}
+	}
+	if len(typed) < required || len(typed) > len(command.Args) && !command.repeats() {
+		return Args{}, fmt.Errorf("Usage: %v", command.Usage())
+	}
+	args := Args{}
+	for i, text := range typed {
+		arg, _ := command.argAt(i)
+		value, err := d.parseArg(arg, text)
+		if err != nil {
+			return Args{}, fmt.Errorf("%v (usage: %v)", err, command.Usage())
+		}
+		args.values = append(args.values, value)
+	}
+	return args, nil
+}
+
+func (d *Dispatcher) parseArg(arg Arg, text string) (interface{}, error) {
+	switch arg.Type {
+	case CityArg:
+		return d.getCityByPrefix(text)
+	case CardArg:
+		return d.getCardByPrefix(text)
+	case PlayerArg:
+		return d.getPlayerByPrefix(text)
+	case DiseaseArg:
+		return d.getDiseaseByPrefix(text)
+	case CommandArg:
+		command, ok := LookupCommand(text)
+		if !ok {
+			return nil, fmt.Errorf("Unrecognized command %v", text)
+		}
+		return command, nil
+	case IntArg:
+		value, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, fmt.Errorf("%v is not a valid %v", text, arg.Name)
+		}
+		return value, nil
+	case WordArg:
+		for _, choice := range arg.Choices {
+			if text == choice {
+				return text, nil
+			}
+		}
+		return nil, fmt.Errorf("%v must be one of %v", arg.Name, strings.Join(arg.Choices, ", "))
+	}
+	return nil, fmt.Errorf("Unknown argument type %v", arg.Type)
+}
+
+// help lists every command, or describes one command in full.
+func (d *Dispatcher) help(out io.Writer, args Args) error {
+	if !args.Has(0) {
+		for _, command := range commands {
+			fmt.Fprintf(out, "%v - %v\n", d.Style.Highlight(command.Usage()), command.Help)
+		}
+		fmt.Fprintln(out, "Type help <command> for more about a command.")
+		return nil
+	}
+	command := args.Command(0)
+	fmt.Fprintf(out, "Usage: %v\n", d.Style.Highlight(command.Usage()))
+	fmt.Fprintln(out, command.Help)
+	if len(command.Aliases) > 0 {
+		fmt.Fprintf(out, "Aliases: %v\n", strings.Join(command.Aliases, ", "))
+	}
+	for _, arg := range command.Args {
+		switch arg.Type {
+		case WordArg:
+			fmt.Fprintf(out, "  %v: one of %v\n", arg, strings.Join(arg.Choices, ", "))
+		case IntArg:
+			fmt.Fprintf(out, "  %v: a number\n", arg)
+		case CommandArg:
+			fmt.Fprintf(out, "  %v: the name of a command\n", arg)
+		default:
+			fmt.Fprintf(out, "  %v: the start of a %v's name\n", arg, arg.Type)
+		}
+	}
+	return nil
+}
+
+var commands []*Command
+
+// commands is filled in by init, since help refers back to it.
+func init() {
+	commands = []*Command{
+		{
+			Name:    "infect",
+			Aliases: []string{"i"},
+			Args:    []Arg{{Name: "city", Type: CityArg, Repeated: true}},
+			Help:    "Draw cities from the infection deck and add a cube to each, all at once.",
+			run:     (*Dispatcher).infect,
+		},
+		{
+			Name:    "epidemic",
+			Aliases: []string{"e"},
+			Args:    []Arg{{Name: "city", Type: CityArg, Optional: true}},
+			Help:    "Resolve an epidemic drawn from the city deck, infecting the city drawn from the bottom of the infection deck. Leave out the city once the infection deck is used up.",
+			run:     (*Dispatcher).epidemic,
+		},
+		{
+			Name:    "city-draw",
+			Aliases: []string{"c"},
+			Args:    []Arg{{Name: "card", Type: CardArg, Repeated: true}},
+			Help:    "Draw city or funded event cards from the city deck into the current player's hand, all at once.",
+			run:     (*Dispatcher).cityDraw,
+		},
+		{
+			Name:    "next-turn",
+			Aliases: []string{"n"},
+			Args:    []Arg{{Name: "force", Type: WordArg, Choices: []string{"force"}, Optional: true}},
+			Help:    "Move on to the next player's turn. Add force to move on before the turn is done.",
+			run:     (*Dispatcher).nextTurn,
+		},
+		{
+			Name:    "give-card",
+			Aliases: []string{"g"},
+			Args:    []Arg{{Name: "player", Type: PlayerArg}, {Name: "card", Type: CardArg}},
+			Help:    "Give a card from the current player's hand to another player.",
+			run:     (*Dispatcher).giveCard,
+		},
+		{
+			Name:          "discard",
+			Aliases:       []string{"d"},
+			Args:          []Arg{{Name: "card", Type: CardArg, Repeated: true}},
+			Help:          "Discard cards, from the hand of whoever is over the hand limit or else the current player, all at once.",
+			OverHandLimit: true,
+			run:           (*Dispatcher).discard,
+		},
+		{
+			Name:          "play-event",
+			Args:          []Arg{{Name: "event", Type: CardArg}},
+			Help:          "Play a funded event from whoever holds it. It is removed from the game.",
+			OverHandLimit: true,
+			run:           (*Dispatcher).playEvent,
+		},
+		{
+			Name:    "infect-rate",
+			Aliases: []string{"r"},
+			Args:    []Arg{{Name: "rate", Type: IntArg}},
+			Help:    "Set the infection rate. It must be on the infection rate track.",
+			run:     (*Dispatcher).infectRate,
+		},
+		{
+			Name:    "city-infect-level",
+			Aliases: []string{"l"},
+			Args:    []Arg{{Name: "city", Type: CityArg}, {Name: "cubes", Type: IntArg}},
+			Help:    "Set the number of cubes on a city.",
+			run:     (*Dispatcher).cityInfectLevel,
+		},
+		{
+			Name:    "quarantine",
+			Aliases: []string{"q"},
+			Args:    []Arg{{Name: "city", Type: CityArg}},
+			Help:    "Place a quarantine marker on a city.",
+			run:     (*Dispatcher).quarantine,
+		},
+		{
+			Name:    "remove-quarantine",
+			Aliases: []string{"rq"},
+			Args:    []Arg{{Name: "city", Type: CityArg}},
+			Help:    "Remove the quarantine marker from a city.",
+			run:     (*Dispatcher).removeQuarantine,
+		},
+		{
+			Name: "cure",
+			Args: []Arg{{Name: "disease", Type: DiseaseArg}},
+			Help: "Cure a disease with the current player's cards, at a research station.",
+			run:  (*Dispatcher).cure,
+		},
+		{
+			Name:    "move",
+			Aliases: []string{"m"},
+			Args:    []Arg{{Name: "player", Type: PlayerArg}, {Name: "city", Type: CityArg}},
+			Help:    "Drive a player to a connected city.",
+			run:     moveWith(pandemic.DriveEvent),
+		},
+		{
+			Name: "direct-flight",
+			Args: []Arg{{Name: "player", Type: PlayerArg}, {Name: "city", Type: CityArg}},
+			Help: "Fly a player to a city by discarding that city's card.",
+			run:  moveWith(pandemic.DirectFlightEvent),
+		},
+		{
+			Name: "charter-flight",
+			Args: []Arg{{Name: "player", Type: PlayerArg}, {Name: "city", Type: CityArg}},
+			Help: "Fly a player anywhere by discarding the card of the city they are in.",
+			run:  moveWith(pandemic.CharterFlightEvent),
+		},
+		{
+			Name: "shuttle",
+			Args: []Arg{{Name: "player", Type: PlayerArg}, {Name: "city", Type: CityArg}},
+			Help: "Fly a player between two research stations.",
+			run:  moveWith(pandemic.ShuttleFlightEvent),
+		},
+		{
+			Name: "build-station",
+			Args: []Arg{{Name: "city", Type: CityArg}},
+			Help: "Build a research station in a city.",
+			run:  buildWith(pandemic.BuildStationEvent, "research station"),
+		},
+		{
+			Name: "build-base",
+			Args: []Arg{{Name: "city", Type: CityArg}},
+			Help: "Build a military base in a city.",
+			run:  buildWith(pandemic.BuildBaseEvent, "military base"),
+		},
+		{
+			Name:          "undo",
+			Aliases:       []string{"u"},
+			Help:          "Take back the last command.",
+			OverHandLimit: true,
+			run:           (*Dispatcher).undo,
+		},
+		{
+			Name:          "redo",
+			Help:          "Apply the last command taken back with undo again.",
+			OverHandLimit: true,
+			run:           (*Dispatcher).redo,
+		},
+		{
+			Name:          "again",
+			Aliases:       []string{"!!"},
+			Help:          "Run the last command again.",
+			OverHandLimit: true,
+			run:           (*Dispatcher).again,
+		},
+		{
+			Name:          "help",
+			Args:          []Arg{{Name: "command", Type: CommandArg, Optional: true}},
+			Help:          "List every command, or describe one.",
+			OverHandLimit: true,
+			run:           (*Dispatcher).help,
+		},
+	}
+}
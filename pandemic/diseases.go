@@ -2,8 +2,17 @@ package pandemic
 
 type DiseaseType string
 
+type CureStatus string
+
+const (
+	Uncured    = CureStatus("")
+	Cured      = CureStatus("cured")
+	Eradicated = CureStatus("eradicated")
+)
+
 type DiseaseData struct {
 	Type             DiseaseType `json:"type"`
+	Status           CureStatus  `json:"status,omitempty"`
 	Incurable        bool        `json:"incurable,omitempty"`
 	Untreatable      bool        `json:"untreatable,omitempty"`
 	BecomingFaded    bool        `json:"becoming_faded,omitempty"`
@@ -15,7 +24,7 @@ var Yellow = DiseaseData{
 }
 var Blue = DiseaseData{
 	Type:          DiseaseType("Blue"),
-	Incurable:     true, // TODO: make configurable with a gamestate
+	Incurable:     true, // override with disease_data in the new game file once curable
 	Untreatable:   true,
 	BecomingFaded: true,
 }
@@ -37,6 +46,13 @@ func (dt DiseaseType) String() string {
 	return string(dt)
 }
 
+func (cs CureStatus) String() string {
+	if cs == Uncured {
+		return "uncured"
+	}
+	return string(cs)
+}
+
 var diseaseDataMap map[DiseaseType]DiseaseData
 
 func init() {
@@ -53,6 +69,9 @@ func DataForDisease(dt DiseaseType) DiseaseData {
 	return diseaseDataMap[dt]
 }
 
+// CurableDiseases lists the diseases that can be cured in a game using
+// the default disease data. See GameState.CurableDiseases for the diseases
+// that can still be cured in a particular game.
 func CurableDiseases() []DiseaseType {
 	ret := []DiseaseType{}
 	for dt, data := range diseaseDataMap {
@@ -0,0 +1,165 @@
+package pandemic
+
+import (
+	"fmt"
+	"strings"
+)
+
+type EventType string
+
+const (
+	InfectEvent           = EventType("infect")
+	EpidemicEvent         = EventType("epidemic")
+	DrawCardEvent         = EventType("draw_card")
+	ExchangeCardEvent     = EventType("exchange_card")
+	DiscardEvent          = EventType("discard")
+	QuarantineEvent       = EventType("quarantine")
+	RemoveQuarantineEvent = EventType("remove_quarantine")
+	InfectionRateEvent    = EventType("infection_rate")
+	InfectionLevelEvent   = EventType("infection_level")
+	NextTurnEvent         = EventType("next_turn")
+	CureEvent             = EventType("cure")
+	DriveEvent            = EventType("drive")
+	DirectFlightEvent     = EventType("direct_flight")
+	CharterFlightEvent    = EventType("charter_flight")
+	ShuttleFlightEvent    = EventType("shuttle_flight")
+	BuildStationEvent     = EventType("build_station")
+	BuildBaseEvent        = EventType("build_base")
+	PlayEventEvent        = EventType("play_event")
+	BatchEvent            = EventType("batch") // applies its Events in order, all or none of them
+)
+
+// An Event is a single change made to a GameState. Every change to a game
+// goes through an Event so that the game can be rebuilt from its log.
+// Which fields are used depends on the Type.
+type Event struct {
+	Type    EventType   `json:"type"`
+	City    CityName    `json:"city,omitempty"`
+	Card    CardName    `json:"card,omitempty"`
+	Player  string      `json:"player,omitempty"`
+	To      string      `json:"to,omitempty"`
+	Value   int         `json:"value,omitempty"`
+	Disease DiseaseType `json:"disease,omitempty"`
+	Force   bool        `json:"force,omitempty"`
+	Events  []Event     `json:"events,omitempty"`
+}
+
+// EventResult carries anything interesting that happened while applying
+// an Event that isn't obvious from the Event itself.
+type EventResult struct {
+	Outbreaks []CityName
+}
+
+func (e Event) String() string {
+	switch e.Type {
+	case InfectEvent, EpidemicEvent, QuarantineEvent, RemoveQuarantineEvent, BuildStationEvent, BuildBaseEvent:
+		return fmt.Sprintf("%v %v", e.Type, e.City)
+	case DrawCardEvent:
+		return fmt.Sprintf("%v %v", e.Type, e.Card)
+	case ExchangeCardEvent:
+		return fmt.Sprintf("%v %v from %v to %v", e.Type, e.Card, e.Player, e.To)
+	case DiscardEvent, PlayEventEvent:
+		return fmt.Sprintf("%v %v by %v", e.Type, e.Card, e.Player)
+	case InfectionRateEvent:
+		return fmt.Sprintf("%v %v", e.Type, e.Value)
+	case InfectionLevelEvent:
+		return fmt.Sprintf("%v %v %v", e.Type, e.City, e.Value)
+	case CureEvent:
+		return fmt.Sprintf("%v %v by %v", e.Type, e.Disease, e.Player)
+	case DriveEvent, DirectFlightEvent, CharterFlightEvent, ShuttleFlightEvent:
+		return fmt.Sprintf("%v %v to %v", e.Type, e.Player, e.City)
+	case BatchEvent:
+		events := make([]string, len(e.Events))
+		for i, batched := range e.Events {
+			events[i] = batched.String()
+		}
+		return strings.Join(events, ", ")
+	}
+	return string(e.Type)
+}
+
+// Apply makes the change described by the event to the game state.
+func (gs *GameState) Apply(e Event) (EventResult, error) {
+	var result EventResult
+	var err error
+	switch e.Type {
+	case InfectEvent:
+		result.Outbreaks, err = gs.Infect(e.City)
+	case EpidemicEvent:
+		result.Outbreaks, err = gs.Epidemic(e.City)
+	case DrawCardEvent:
+		err = gs.DrawCard(e.Card)
+	case ExchangeCardEvent:
+		from, err := gs.GameTurns.GetPlayer(e.Player)
+		if err != nil {
+			return result, err
+		}
+		to, err := gs.GameTurns.GetPlayer(e.To)
+		if err != nil {
+			return result, err
+		}
+		return result, gs.ExchangeCard(from, to, e.Card)
+	case DiscardEvent:
+		player, err := gs.GameTurns.GetPlayer(e.Player)
+		if err != nil {
+			return result, err
+		}
+		return result, gs.DiscardCard(player, e.Card)
+	case PlayEventEvent:
+		player, err := gs.GameTurns.GetPlayer(e.Player)
+		if err != nil {
+			return result, err
+		}
+		return result, gs.PlayEvent(player, e.Card)
+	case QuarantineEvent:
+		err = gs.Quarantine(e.City)
+	case RemoveQuarantineEvent:
+		err = gs.RemoveQuarantine(e.City)
+	case BuildStationEvent:
+		err = gs.BuildResearchStation(e.City)
+	case BuildBaseEvent:
+		err = gs.BuildMilitaryBase(e.City)
+	case InfectionRateEvent:
+		err = gs.SetInfectionRate(e.Value)
+	case InfectionLevelEvent:
+		var city *City
+		city, err = gs.GetCity(e.City)
+		if err == nil {
+			city.SetInfections(e.Value)
+			gs.checkEradication()
+		}
+	case NextTurnEvent:
+		_, err = gs.NextTurn(e.Force)
+	case CureEvent:
+		player, err := gs.GameTurns.GetPlayer(e.Player)
+		if err != nil {
+			return result, err
+		}
+		return result, gs.Cure(player, e.Disease)
+	case DriveEvent, DirectFlightEvent, CharterFlightEvent, ShuttleFlightEvent:
+		player, err := gs.GameTurns.GetPlayer(e.Player)
+		if err != nil {
+			return result, err
+		}
+		move := map[EventType]func(*Player, CityName) error{
+			DriveEvent:         gs.Drive,
+			DirectFlightEvent:  gs.DirectFlight,
+			CharterFlightEvent: gs.CharterFlight,
+			ShuttleFlightEvent: gs.ShuttleFlight,
+		}[e.Type]
+		return result, move(player, e.City)
+	case BatchEvent:
+		// a failure part way through leaves the game half changed; the
+		// journal rebuilds it so that none of the batch is applied
+		for _, batched := range e.Events {
+			batchedResult, err := gs.Apply(batched)
+			result.Outbreaks = append(result.Outbreaks, batchedResult.Outbreaks...)
+			if err != nil {
+				return result, fmt.Errorf("%v: %v", batched, err)
+			}
+		}
+	default:
+		err = fmt.Errorf("Unknown event type %v", e.Type)
+	}
+	return result, err
+}
@@ -12,22 +12,37 @@ import (
 
 const EpidemicsPerGame = 5
 const CityCardsPerTurn = 2
+const MaxResearchStations = 6
+const HandLimit = 7
+
+// The Legacy infection rate track, indexed by the number of epidemics drawn.
+var DefaultInfectionRateTrack = []int{2, 2, 2, 3, 3, 4, 4}
 
 type GameState struct {
-	Cities        *Cities        `json:"cities"`
-	CityDeck      *CityDeck      `json:"city_deck"`
-	DiseaseData   []DiseaseData  `json:"disease_data"`
-	InfectionDeck *InfectionDeck `json:"infection_deck"`
-	InfectionRate int            `json:"infection_rate"`
-	Outbreaks     int            `json:"outbreaks"`
-	GameName      string         `json:"game_name"`
-	GameTurns     *GameTurns     `json:"game_turns"`
+	FormatVersion int             `json:"format_version"`
+	Cities        *Cities         `json:"cities"`
+	CityDeck      *CityDeck       `json:"city_deck"`
+	DiseaseData   []DiseaseData   `json:"disease_data"`
+	InfectionDeck *InfectionDeck  `json:"infection_deck"`
+	InfectionRate int             `json:"infection_rate"`
+	Outbreaks     int             `json:"outbreaks"`
+	GameName      string          `json:"game_name"`
+	GameTurns     *GameTurns      `json:"game_turns"`
+	PlayerDiscard []DiscardedCard `json:"player_discard"`
+
+	// Funded events are removed from the game once played.
+	RemovedFromGame []DiscardedCard `json:"removed_from_game"`
+
+	// InfectionRateTrack is empty in games saved before it was tracked,
+	// in which case DefaultInfectionRateTrack is used.
+	InfectionRateTrack []int `json:"infection_rate_track,omitempty"`
 }
 
 type NewGameSettings struct {
 	Cities       Cities         `json:"cities"`
 	Players      []*Player      `json:"players"`
 	FundedEvents []*FundedEvent `json:"funded_events"`
+	DiseaseData  []DiseaseData  `json:"disease_data"`
 }
 
 func NewGame(newGameFile string, gameName string) (*GameState, error) {
@@ -46,14 +61,14 @@ func NewGame(newGameFile string, gameName string) (*GameState, error) {
 	excludeFromCityDeck := Set{}
 	for _, player := range players {
 		if len(player.StartCards) != 2 {
-			return nil, fmt.Errorf("Each player must start with 2 city cards")
+			return nil, fmt.Errorf("Each player must start with 2 cards")
 		}
 		for _, cityName := range player.StartCards {
 			excludeFromCityDeck.Add(cityName)
 		}
 	}
 	if len(excludeFromCityDeck) != 2*len(players) {
-		return nil, fmt.Errorf("Duplicate cities detected, check the start information: %+v", excludeFromCityDeck)
+		return nil, fmt.Errorf("Duplicate start cards detected, check the start information: %+v", excludeFromCityDeck)
 	}
 
 	cityDeck, err := cities.GenerateCityDeck(EpidemicsPerGame, newGameSettings.FundedEvents, excludeFromCityDeck)
@@ -61,49 +76,223 @@ func NewGame(newGameFile string, gameName string) (*GameState, error) {
 		return nil, err
 	}
 
+	startCity, err := cities.GetCity(StartCity)
+	startInAtlanta := err == nil
+	if startInAtlanta {
+		startCity.ResearchStation = true
+	}
 	for _, player := range players {
+		if player.Location.Empty() && startInAtlanta {
+			player.Location = StartCity
+		}
 		for _, startCard := range player.StartCards {
 			card, err := cityDeck.GetCard(startCard)
 			if err != nil {
-				return nil, fmt.Errorf("%v is not a valid start city: %v", startCard, err)
+				return nil, fmt.Errorf("%v is not a valid start card: %v", startCard, err)
 			}
 			player.Cards = append(player.Cards, card)
 		}
 	}
 
+	diseaseData := newGameSettings.DiseaseData
+	if len(diseaseData) == 0 {
+		diseaseData = []DiseaseData{Yellow, Red, Black, Blue, Faded}
+	}
+
 	infectionDeck := NewInfectionDeck(cities.CityNames())
 	return &GameState{
-		Cities:        &cities,
-		DiseaseData:   []DiseaseData{Yellow, Red, Black, Blue, Faded},
-		CityDeck:      &cityDeck,
-		InfectionDeck: infectionDeck,
-		InfectionRate: 2,
-		Outbreaks:     0,
-		GameName:      gameName,
-		GameTurns:     InitGameTurns(players...),
+		FormatVersion:      CurrentFormatVersion,
+		Cities:             &cities,
+		DiseaseData:        diseaseData,
+		CityDeck:           &cityDeck,
+		InfectionDeck:      infectionDeck,
+		InfectionRate:      DefaultInfectionRateTrack[0],
+		InfectionRateTrack: append([]int{}, DefaultInfectionRateTrack...),
+		Outbreaks:          0,
+		GameName:           gameName,
+		GameTurns:          InitGameTurns(players...),
+		PlayerDiscard:      []DiscardedCard{},
+		RemovedFromGame:    []DiscardedCard{},
 	}, nil
 }
 
 func LoadGame(gameFile string) (*GameState, error) {
-	var gameState GameState
 	data, err := ioutil.ReadFile(gameFile)
 	if err != nil {
 		return nil, err
 	}
+	data, err = unwrapSave(data)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", gameFile, err)
+	}
+	return loadGameData(data)
+}
+
+// Clone makes a deep copy of the game state.
+func (gs *GameState) Clone() (*GameState, error) {
+	data, err := json.Marshal(gs)
+	if err != nil {
+		return nil, err
+	}
+	return loadGameData(data)
+}
+
+func loadGameData(data []byte) (*GameState, error) {
+	data, err := migrate(data)
+	if err != nil {
+		return nil, err
+	}
+	var gameState GameState
 	err = json.Unmarshal(data, &gameState)
 	if err != nil {
 		return nil, err
 	}
+	// GameTurns links turns back to their players as it loads. The cards
+	// in hands and turns still need to point back at the city deck.
+	gameState.relinkCards()
 	return &gameState, nil
 }
 
+// relinkCards points every card reference at the card in the city deck.
+func (gs *GameState) relinkCards() {
+	deck := gs.CityDeck
+	if deck == nil {
+		return
+	}
+	deck.relink()
+	if gs.GameTurns != nil {
+		for _, player := range gs.GameTurns.PlayerOrder {
+			for i, card := range player.Cards {
+				player.Cards[i] = deck.canonical(card)
+			}
+		}
+		for _, turn := range gs.GameTurns.Turns {
+			for i, card := range turn.DrawnCards {
+				turn.DrawnCards[i] = deck.canonical(card)
+			}
+		}
+	}
+	for i := range gs.PlayerDiscard {
+		gs.PlayerDiscard[i].Card = deck.canonical(gs.PlayerDiscard[i].Card)
+	}
+	for i := range gs.RemovedFromGame {
+		gs.RemovedFromGame[i].Card = deck.canonical(gs.RemovedFromGame[i].Card)
+	}
+}
+
+// CardsToCure is the number of cards of the given disease the player must
+// hold to discover a cure. Returns false if the player can never cure.
+func CardsToCure(player *Player, dt DiseaseType) (int, bool) {
+	// TODO: make disease curability more programatic
+	required := 5
+	if dt == Red.Type || dt == Black.Type {
+		required = 4
+	}
+	if player.Character != nil {
+		switch player.Character.Type {
+		case Scientist:
+			required--
+		case Colonel:
+			required += 2
+		case Soldier:
+			return 0, false
+		}
+	}
+	return required, true
+}
+
+// CurableDiseases lists the diseases in this game that can still be cured.
+func (gs *GameState) CurableDiseases() []DiseaseType {
+	ret := []DiseaseType{}
+	for _, data := range gs.DiseaseData {
+		if !data.Incurable && data.Status == Uncured {
+			ret = append(ret, data.Type)
+		}
+	}
+	return ret
+}
+
+// Cure discovers a cure for the disease, discarding the cards it takes
+// from the player's hand. A cured disease with no cubes left on the board
+// is eradicated straight away.
+func (gs *GameState) Cure(player *Player, dt DiseaseType) error {
+	data, err := gs.GetDiseaseData(dt)
+	if err != nil {
+		return err
+	}
+	if data.Incurable {
+		return fmt.Errorf("%v cannot be cured", dt)
+	}
+	if data.Status != Uncured {
+		return fmt.Errorf("%v is already %v", dt, data.Status)
+	}
+	required, canCure := CardsToCure(player, dt)
+	if !canCure {
+		return fmt.Errorf("%v cannot discover cures", player.HumanName)
+	}
+	if !gs.hasResearchStation(player.Location) {
+		return fmt.Errorf("%v must be at a research station to cure", player.HumanName)
+	}
+	toDiscard := []CardName{}
+	for _, card := range player.Cards {
+		if len(toDiscard) == required {
+			break
+		}
+		if !card.IsCity() {
+			continue
+		}
+		city, err := gs.Cities.GetCity(card.CityName)
+		if err == nil && city.Disease == dt {
+			toDiscard = append(toDiscard, card.Name())
+		}
+	}
+	if len(toDiscard) < required {
+		return fmt.Errorf("%v needs %v %v cards to cure but only has %v", player.HumanName, required, dt, len(toDiscard))
+	}
+	for _, name := range toDiscard {
+		if err := gs.DiscardCard(player, name); err != nil {
+			return err
+		}
+	}
+	data.Status = Cured
+	gs.checkEradication()
+	return nil
+}
+
+// IsEradicated is true once a disease has been cured and every one of its
+// cubes has been removed from the board. Eradicated diseases don't infect.
+func (gs *GameState) IsEradicated(dt DiseaseType) bool {
+	data, err := gs.GetDiseaseData(dt)
+	return err == nil && data.Status == Eradicated
+}
+
+// checkEradication marks every cured disease with no cubes left on the
+// board as eradicated.
+func (gs *GameState) checkEradication() {
+	for i := range gs.DiseaseData {
+		data := &gs.DiseaseData[i]
+		if data.Status != Cured {
+			continue
+		}
+		eradicated := true
+		for _, city := range *gs.Cities {
+			if city.Disease == data.Type && city.NumInfections > 0 {
+				eradicated = false
+				break
+			}
+		}
+		if eradicated {
+			data.Status = Eradicated
+		}
+	}
+}
+
 func (gs GameState) ProbabilityOfCuring(player *Player, dt DiseaseType) float64 {
 	// (diseaseColor choose requiredToCure)*(notDiseaseColor choose totalLessRequired)/(allCards choose totalExpectedDraws)
 	remainingCards := gs.CityDeck.RemainingCardsWith(dt, gs.Cities)
-	// TODO: make disease curability more programatic
-	totalRequired := 5
-	if dt == Red.Type || dt == Black.Type {
-		totalRequired = 4
+	totalRequired, canCure := CardsToCure(player, dt)
+	if !canCure {
+		return 0.0
 	}
 	for _, card := range player.Cards {
 		if !card.IsCity() {
@@ -117,15 +306,6 @@ func (gs GameState) ProbabilityOfCuring(player *Player, dt DiseaseType) float64
 			totalRequired--
 		}
 	}
-	if player.Character != nil {
-		if player.Character.Type == Scientist {
-			totalRequired--
-		} else if player.Character.Type == Colonel {
-			totalRequired += 2
-		} else if player.Character.Type == Soldier {
-			return 0.0
-		}
-	}
 
 	allRemaining := gs.CityDeck.RemainingCards()
 	drawsRemaining := 2 * (gs.GameTurns.RemainingTurnsFor(allRemaining, player.HumanName) - 1) // you don't get to use your last draw
@@ -137,7 +317,7 @@ func (gs GameState) DrawCard(cn CardName) error {
 	if err != nil {
 		return err
 	}
-	if len(curTurn.DrawnCards) == CityCardsPerTurn {
+	if curTurn.Draws() >= CityCardsPerTurn {
 		return fmt.Errorf("%v has already drawn %v cards this turn.", curTurn.Player.HumanName, CityCardsPerTurn)
 	}
 	card, err := gs.CityDeck.DrawCard(cn)
@@ -149,10 +329,92 @@ func (gs GameState) DrawCard(cn CardName) error {
 	return nil
 }
 
-func (gs GameState) NextTurn() (*Turn, error) {
+// NextTurn moves on to the next player once the current turn has drawn
+// its city cards and infected InfectionRate cities. Pass force to move on
+// regardless, such as when a step was played but never entered.
+func (gs GameState) NextTurn(force bool) (*Turn, error) {
+	curTurn, err := gs.GameTurns.CurrentTurn()
+	if err != nil {
+		return nil, err
+	}
+	if !force {
+		switch phase := curTurn.Phase(gs.InfectionRate); phase {
+		case ActionsPhase, DrawPhase:
+			return nil, fmt.Errorf("%v has only drawn %v of %v city cards", curTurn.Player.HumanName, curTurn.Draws(), CityCardsPerTurn)
+		case EpidemicPhase, InfectPhase:
+			return nil, fmt.Errorf("Only %v of %v infection cards have been drawn", curTurn.Infections, gs.InfectionRate)
+		}
+	}
 	return gs.GameTurns.NextTurn()
 }
 
+// A DiscardedCard is a player card that has left a hand, along with who
+// held it and the turn it was discarded on.
+type DiscardedCard struct {
+	Card   *CityCard `json:"card"`
+	Player string    `json:"player"`
+	Turn   int       `json:"turn"`
+}
+
+// DiscardCard moves a card from the player's hand to the player discard pile.
+func (gs *GameState) DiscardCard(player *Player, name CardName) error {
+	card, err := player.Discard(name)
+	if err != nil {
+		return err
+	}
+	gs.PlayerDiscard = append(gs.PlayerDiscard, DiscardedCard{card, player.HumanName, gs.GameTurns.CurTurn})
+	return nil
+}
+
+// PlayEvent plays a funded event from the player's hand. Legacy removes
+// funded events from the game once played rather than discarding them.
+func (gs *GameState) PlayEvent(player *Player, name CardName) error {
+	var event *CityCard
+	for _, card := range player.Cards {
+		if card.Name() == name {
+			event = card
+		}
+	}
+	if event == nil {
+		return fmt.Errorf("%v does not seem to have %v", player.HumanName, name)
+	}
+	if !event.IsFundedEvent() {
+		return fmt.Errorf("%v is not a funded event", name)
+	}
+	if _, err := player.Discard(name); err != nil {
+		return err
+	}
+	gs.RemovedFromGame = append(gs.RemovedFromGame, DiscardedCard{event, player.HumanName, gs.GameTurns.CurTurn})
+	return nil
+}
+
+// CardHolder finds the player holding the card, or nil if nobody has it.
+func (gs *GameState) CardHolder(name CardName) *Player {
+	for _, player := range gs.GameTurns.PlayerOrder {
+		for _, card := range player.Cards {
+			if card.Name() == name {
+				return player
+			}
+		}
+	}
+	return nil
+}
+
+// PlayerOverHandLimit returns the first player holding more than HandLimit
+// cards, who must discard before the game can go on. Returns nil if every
+// hand is within the limit.
+func (gs *GameState) PlayerOverHandLimit() *Player {
+	if gs.GameTurns == nil {
+		return nil
+	}
+	for _, player := range gs.GameTurns.PlayerOrder {
+		if player.OverHandLimit() {
+			return player
+		}
+	}
+	return nil
+}
+
 func (gs GameState) ExchangeCard(from, to *Player, name CardName) error {
 	var senderNewCards []*CityCard
 	var toGive *CityCard
@@ -171,46 +433,175 @@ func (gs GameState) ExchangeCard(from, to *Player, name CardName) error {
 	return nil
 }
 
-func (gs GameState) Infect(cn CityName) error {
+// Infect draws the given city from the infection deck and places a cube on
+// it. A city that already has 3 cubes outbreaks instead, which may cascade
+// through its neighbors. The returned slice lists every city that outbroke,
+// in the order the chain was resolved.
+func (gs *GameState) Infect(cn CityName) ([]CityName, error) {
+	if _, err := gs.Cities.GetCity(cn); err != nil {
+		return nil, err
+	}
 	err := gs.InfectionDeck.Draw(cn)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	// Infections before the city cards are drawn are part of setting up
+	// the game rather than the infect step.
+	if curTurn, err := gs.GameTurns.CurrentTurn(); err == nil && curTurn.Draws() >= CityCardsPerTurn {
+		curTurn.Infections++
+	}
+	return gs.AddCube(cn)
+}
+
+// AddCube places a single cube on the city without drawing from the
+// infection deck, outbreaking if the city is already at 3 cubes.
+func (gs *GameState) AddCube(cn CityName) ([]CityName, error) {
 	city, err := gs.Cities.GetCity(cn)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if city.Quarantined {
-		if !gs.quarantineSpecialistPresent(cn) {
-			city.RemoveQuarantine()
-		}
-		return nil
+	if gs.IsEradicated(city.Disease) || gs.blockedByQuarantine(city) {
+		return nil, nil
 	}
-	// TODO: handle outbreaks
-	city.Infect()
-	return nil
+	if city.Infect() {
+		return gs.outbreak(cn)
+	}
+	return nil, nil
 }
 
-func (gs GameState) Epidemic(cn CityName) error {
-	err := gs.InfectionDeck.PullFromBottom(cn)
+// Epidemic resolves an epidemic card drawn from the city deck: the
+// infection rate moves along its track, the given city is pulled from the
+// bottom of the infection deck and set to 3 cubes, and the infection
+// discard pile is shuffled back on top. If the city already had cubes it
+// outbreaks. Everything an epidemic needs is checked before the game is
+// changed; only a city with an unknown neighbor can fail part way through
+// an outbreak, and Journal.Record rebuilds the game when that happens.
+//
+// Once every infection card has been drawn there is no bottom card to
+// pull, so pass an empty city name to only increase and intensify.
+func (gs *GameState) Epidemic(cn CityName) ([]CityName, error) {
+	curTurn, err := gs.GameTurns.CurrentTurn()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	err = gs.CityDeck.DrawEpidemic()
-	if err != nil {
-		return err
+	if curTurn.Draws() >= CityCardsPerTurn {
+		return nil, fmt.Errorf("%v has already drawn %v cards this turn.", curTurn.Player.HumanName, CityCardsPerTurn)
 	}
-	city, _ := gs.Cities.GetCity(cn)
-
-	if city.Quarantined {
-		if !gs.quarantineSpecialistPresent(cn) {
-			city.RemoveQuarantine()
+	if gs.CityDeck.EpidemicsDrawn() >= gs.CityDeck.NumEpidemics() {
+		return nil, fmt.Errorf("Already drawn %v epidemics this game, there shouldn't be any more", gs.CityDeck.EpidemicsDrawn())
+	}
+	var city *City
+	if cn.Empty() {
+		if len(gs.InfectionDeck.Striations) > 0 {
+			return nil, fmt.Errorf("An epidemic must pull a city from the bottom of the infection deck")
 		}
 	} else {
-		// TODO: handle outbreak
-		city.Epidemic()
+		if city, err = gs.Cities.GetCity(cn); err != nil {
+			return nil, err
+		}
+		if len(gs.InfectionDeck.Striations) == 0 || !gs.InfectionDeck.BottomStriation().Contains(cn) {
+			return nil, fmt.Errorf("Card %v should not be present in the bottom striation", cn)
+		}
+	}
+	return gs.resolveEpidemic(curTurn, city)
+}
+
+func (gs *GameState) resolveEpidemic(curTurn *Turn, city *City) ([]CityName, error) {
+	if err := gs.CityDeck.DrawEpidemic(); err != nil {
+		return nil, err
+	}
+	curTurn.Epidemics++
+	gs.InfectionRate = gs.InfectionRateAfter(gs.CityDeck.EpidemicsDrawn())
+
+	var chain []CityName
+	if city != nil {
+		if err := gs.InfectionDeck.PullFromBottom(city.Name); err != nil {
+			return nil, err
+		}
+		if !gs.IsEradicated(city.Disease) && !gs.blockedByQuarantine(city) && city.Epidemic() {
+			var err error
+			if chain, err = gs.outbreak(city.Name); err != nil {
+				return nil, err
+			}
+		}
 	}
 	gs.InfectionDeck.ShuffleDrawn()
+	return chain, nil
+}
+
+// InfectionRateAfter looks up the infection rate on the track once the
+// given number of epidemics have been drawn. The rate stays at the end of
+// the track after running off it.
+func (gs *GameState) InfectionRateAfter(epidemics int) int {
+	track := gs.InfectionRateTrack
+	if len(track) == 0 {
+		track = DefaultInfectionRateTrack
+	}
+	if epidemics >= len(track) {
+		return track[len(track)-1]
+	}
+	return track[epidemics]
+}
+
+// SetInfectionRate corrects the infection rate by hand. Only rates that
+// appear on the track are accepted.
+func (gs *GameState) SetInfectionRate(rate int) error {
+	track := gs.InfectionRateTrack
+	if len(track) == 0 {
+		track = DefaultInfectionRateTrack
+	}
+	for _, onTrack := range track {
+		if rate == onTrack {
+			gs.InfectionRate = rate
+			return nil
+		}
+	}
+	return fmt.Errorf("%v is not on the infection rate track %v", rate, track)
+}
+
+// Quarantined cities do not receive cubes. Unless the Quarantine Specialist
+// is present, the quarantine is used up by blocking the infection.
+func (gs *GameState) blockedByQuarantine(city *City) bool {
+	if !city.Quarantined {
+		return false
+	}
+	if !gs.quarantineSpecialistPresent(city.Name) {
+		city.RemoveQuarantine()
+	}
+	return true
+}
+
+// outbreak places a cube in each neighbor of the given city, cascading into
+// further outbreaks. Each city outbreaks at most once per chain.
+func (gs *GameState) outbreak(cn CityName) ([]CityName, error) {
+	chain := []CityName{}
+	err := gs.cascadeOutbreak(cn, Set{}, &chain)
+	return chain, err
+}
+
+func (gs *GameState) cascadeOutbreak(cn CityName, outbroke Set, chain *[]CityName) error {
+	city, err := gs.Cities.GetCity(cn)
+	if err != nil {
+		return err
+	}
+	outbroke.Add(cn)
+	*chain = append(*chain, cn)
+	gs.Outbreaks++
+	for _, neighborName := range city.Neighbors {
+		neighbor, err := gs.Cities.GetCity(CityName(neighborName))
+		if err != nil {
+			return fmt.Errorf("%v has an unknown neighbor: %v", cn, err)
+		}
+		if outbroke.Contains(neighbor.Name) || gs.IsEradicated(neighbor.Disease) || gs.blockedByQuarantine(neighbor) {
+			continue
+		}
+		if neighbor.Infect() {
+			err = gs.cascadeOutbreak(neighbor.Name, outbroke, chain)
+			if err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
@@ -249,16 +640,68 @@ func (gs GameState) RemoveQuarantine(cn CityName) error {
 	return nil
 }
 
+func (gs *GameState) ResearchStations() []CityName {
+	stations := []CityName{}
+	for _, city := range *gs.Cities {
+		if city.ResearchStation {
+			stations = append(stations, city.Name)
+		}
+	}
+	return stations
+}
+
+// BuildResearchStation places a research station in the city, as long as
+// the city isn't panicking too much to build and there is a station left.
+func (gs *GameState) BuildResearchStation(cn CityName) error {
+	city, err := gs.Cities.GetCity(cn)
+	if err != nil {
+		return err
+	}
+	if city.ResearchStation {
+		return fmt.Errorf("%v already has a research station", cn)
+	}
+	if !city.CanBuild() {
+		return fmt.Errorf("%v is %v and cannot build", cn, city.PanicLevel)
+	}
+	if len(gs.ResearchStations()) >= MaxResearchStations {
+		return fmt.Errorf("All %v research stations have been built", MaxResearchStations)
+	}
+	city.ResearchStation = true
+	return nil
+}
+
+// BuildMilitaryBase places a military base in the city, as long as the
+// city isn't panicking too much to build.
+func (gs *GameState) BuildMilitaryBase(cn CityName) error {
+	city, err := gs.Cities.GetCity(cn)
+	if err != nil {
+		return err
+	}
+	if city.MilitaryBase {
+		return fmt.Errorf("%v already has a military base", cn)
+	}
+	if !city.CanBuild() {
+		return fmt.Errorf("%v is %v and cannot build", cn, city.PanicLevel)
+	}
+	city.MilitaryBase = true
+	return nil
+}
+
+func (gs *GameState) hasResearchStation(cn CityName) bool {
+	city, err := gs.Cities.GetCity(cn)
+	return err == nil && city.ResearchStation
+}
+
 // ProbabilityOfCity gives the aggregate probability of a city
-// becoming infected. Quarantines make the probabilty of infection
-// zero. This does not take into account the probability of infection
-// due to neighboring city outbreaks.
+// becoming infected. Quarantines and eradication make the probabilty of
+// infection zero. This does not take into account the probability of infection
+// due to neighboring city outbreaks; see ProbabilityOfCube for that.
 func (gs GameState) ProbabilityOfCity(cn CityName) float64 {
 	city, err := gs.Cities.GetCity(cn)
 	if err != nil {
 		return 0.0
 	}
-	if city.Quarantined {
+	if city.Quarantined || gs.IsEradicated(city.Disease) {
 		return 0.0
 	}
 	var cityDrawInfectRate float64
@@ -281,6 +724,41 @@ func (gs GameState) ProbabilityOfCity(cn CityName) float64 {
 	return cityDrawInfectRate + pEpi*pEpiDraw + (1.0-pEpi)*pNoEpiDraw
 }
 
+// ProbabilityOfOutbreak gives the probability that a city outbreaks this
+// turn, either by being drawn while at 3 cubes or by being pulled from the
+// bottom of the infection deck during an epidemic while it has any cubes.
+// Chained outbreaks are not taken into account.
+func (gs GameState) ProbabilityOfOutbreak(cn CityName) float64 {
+	city, err := gs.Cities.GetCity(cn)
+	if err != nil || city.Quarantined || gs.IsEradicated(city.Disease) {
+		return 0.0
+	}
+	if city.NumInfections == 3 {
+		return gs.ProbabilityOfCity(cn)
+	}
+	bottom := gs.InfectionDeck.BottomStriation()
+	if city.NumInfections > 0 && bottom.Contains(cn) {
+		return gs.CityDeck.probabilityOfEpidemic() / float64(bottom.Size())
+	}
+	return 0.0
+}
+
+// ProbabilityOfCube gives the probability that a city receives a cube this
+// turn, either from being infected itself or from any of its neighbors
+// outbreaking. Each source is treated as independent. Neighbors whose
+// disease is eradicated can't outbreak, so they add nothing.
+func (gs GameState) ProbabilityOfCube(cn CityName) float64 {
+	city, err := gs.Cities.GetCity(cn)
+	if err != nil || city.Quarantined || gs.IsEradicated(city.Disease) {
+		return 0.0
+	}
+	noCube := 1.0 - gs.ProbabilityOfCity(cn)
+	for _, neighbor := range city.Neighbors {
+		noCube *= 1.0 - gs.ProbabilityOfOutbreak(CityName(neighbor))
+	}
+	return 1.0 - noCube
+}
+
 func (gs GameState) CanOutbreak(cn CityName) bool {
 	city, err := gs.Cities.GetCity(cn)
 	if err != nil {
@@ -289,7 +767,7 @@ func (gs GameState) CanOutbreak(cn CityName) bool {
 	if city.NumInfections == 0 && !DataForDisease(city.Disease).InfectOnCityDraw {
 		return false
 	}
-	prob := gs.ProbabilityOfCity(cn)
+	prob := gs.ProbabilityOfCube(cn)
 	if prob == 0.0 {
 		return false
 	}
@@ -301,9 +779,9 @@ func (gs *GameState) GetCity(city CityName) (*City, error) {
 }
 
 func (gs *GameState) GetDiseaseData(diseaseType DiseaseType) (*DiseaseData, error) {
-	for _, data := range gs.DiseaseData {
-		if data.Type == diseaseType {
-			return &data, nil
+	for i := range gs.DiseaseData {
+		if gs.DiseaseData[i].Type == diseaseType {
+			return &gs.DiseaseData[i], nil
 		}
 	}
 	return nil, fmt.Errorf("No disease identified by %v", diseaseType)
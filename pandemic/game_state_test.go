@@ -6,13 +6,13 @@ import (
 	"testing"
 )
 
-func getNumCards(count int, numEpis int) []CityCard {
-	cards := make([]CityCard, count)
+func getNumCards(count int, numEpis int) []*CityCard {
+	cards := make([]*CityCard, count)
 	for x := 0; x < count-numEpis; x++ {
-		cards[x] = CityCard{CityName(fmt.Sprintf("testCity%v", x)), false, ""}
+		cards[x] = &CityCard{CityName: CityName(fmt.Sprintf("testCity%v", x))}
 	}
 	for x := count - numEpis; x < count; x++ {
-		cards[x] = CityCard{"", true, ""}
+		cards[x] = &CityCard{IsEpidemic: true, EpidemicNumber: x - (count - numEpis) + 1}
 	}
 	return cards
 }
@@ -21,7 +21,7 @@ func TestCardProbabilities(t *testing.T) {
 	model := generateProbabilityModel(100, EpidemicsPerGame)
 	deck := &CityDeck{
 		All:              getNumCards(100, EpidemicsPerGame),
-		Drawn:            []CityCard{},
+		Drawn:            []*CityCard{},
 		ProbabilityModel: &model,
 	}
 	if prob := deck.probabilityOfEpidemic(); prob != 0.1 {
@@ -358,3 +358,283 @@ func TestSortByInfect(t *testing.T) {
 		t.Fatalf("Incorrect order: %+v", sorted)
 	}
 }
+
+func getOutbreakTestGame() *GameState {
+	cities := Cities([]*City{
+		{Name: "a", Disease: Blue.Type, Neighbors: []string{"b", "c"}, NumInfections: 3},
+		{Name: "b", Disease: Blue.Type, Neighbors: []string{"a", "d"}, NumInfections: 3},
+		{Name: "c", Disease: Blue.Type, Neighbors: []string{"a"}, NumInfections: 1},
+		{Name: "d", Disease: Yellow.Type, Neighbors: []string{"b"}, Quarantined: true},
+	})
+	return &GameState{
+		Cities:        &cities,
+		InfectionDeck: NewInfectionDeck(cities.CityNames()),
+		GameTurns:     InitGameTurns(),
+	}
+}
+
+func TestInfectCascadesOutbreaks(t *testing.T) {
+	gs := getOutbreakTestGame()
+	chain, err := gs.Infect("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain) != 2 || chain[0] != "a" || chain[1] != "b" {
+		t.Fatalf("Expected a -> b outbreak chain, got %v", chain)
+	}
+	if gs.Outbreaks != 2 {
+		t.Fatalf("Expected 2 outbreaks, got %v", gs.Outbreaks)
+	}
+	expected := map[CityName]int{"a": 3, "b": 3, "c": 2, "d": 0}
+	for name, infections := range expected {
+		city, _ := gs.GetCity(name)
+		if city.NumInfections != infections {
+			t.Errorf("Expected %v to have %v infections, got %v", name, infections, city.NumInfections)
+		}
+	}
+	d, _ := gs.GetCity("d")
+	if d.Quarantined {
+		t.Error("Expected the outbreak into d to use up its quarantine")
+	}
+}
+
+func TestInfectWithoutOutbreak(t *testing.T) {
+	gs := getOutbreakTestGame()
+	chain, err := gs.Infect("c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain) != 0 || gs.Outbreaks != 0 {
+		t.Fatalf("Did not expect an outbreak, got %v", chain)
+	}
+	c, _ := gs.GetCity("c")
+	if c.NumInfections != 2 {
+		t.Fatalf("Expected c to have 2 infections, got %v", c.NumInfections)
+	}
+}
+
+func TestProbabilityOfCubeFromNeighbors(t *testing.T) {
+	cities := Cities([]*City{
+		{Name: "a", Disease: Blue.Type, OriginalDisease: Blue.Type, Neighbors: []string{"b"}, NumInfections: 3},
+		{Name: "b", Disease: Blue.Type, OriginalDisease: Blue.Type, Neighbors: []string{"a", "c"}},
+		{Name: "c", Disease: Blue.Type, OriginalDisease: Blue.Type, Neighbors: []string{"b"}, Quarantined: true},
+	})
+	deck, err := cities.GenerateCityDeck(1, []*FundedEvent{}, Set{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deck.DrawEpidemic() // no more epidemics, so only infection draws matter
+	gs := GameState{
+		Cities:        &cities,
+		CityDeck:      &deck,
+		InfectionDeck: NewInfectionDeck(cities.CityNames()),
+		InfectionRate: 2,
+	}
+
+	// 2 draws out of 3 cards
+	if prob := gs.ProbabilityOfOutbreak("a"); math.Abs(prob-2.0/3.0) > 0.001 {
+		t.Errorf("Expected a to outbreak with probability 2/3, got %v", prob)
+	}
+	if prob := gs.ProbabilityOfOutbreak("b"); prob != 0.0 {
+		t.Errorf("b has no cubes and should not outbreak, got %v", prob)
+	}
+	// b avoids a cube only if neither b nor a is drawn: 1 - 1/3 * 1/3
+	if prob := gs.ProbabilityOfCube("b"); math.Abs(prob-8.0/9.0) > 0.001 {
+		t.Errorf("Expected b to receive a cube with probability 8/9, got %v", prob)
+	}
+	if prob := gs.ProbabilityOfCube("c"); prob != 0.0 {
+		t.Errorf("c is quarantined and should not receive a cube, got %v", prob)
+	}
+	if !gs.CanOutbreak("a") || gs.CanOutbreak("b") {
+		t.Error("Only a should be able to outbreak")
+	}
+}
+
+func TestProbabilityOfCubeWithEradication(t *testing.T) {
+	cities := Cities([]*City{
+		{Name: "a", Disease: Blue.Type, OriginalDisease: Blue.Type, Neighbors: []string{"b"}, NumInfections: 1},
+		{Name: "b", Disease: Black.Type, OriginalDisease: Black.Type, Neighbors: []string{"a", "c"}},
+		{Name: "c", Disease: Blue.Type, OriginalDisease: Blue.Type, Neighbors: []string{"b"}},
+	})
+	deck, err := cities.GenerateCityDeck(1, []*FundedEvent{}, Set{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the epidemic is still to come, so a could be pulled from the bottom
+	blue := Blue
+	blue.Status = Eradicated
+	gs := GameState{
+		Cities:        &cities,
+		CityDeck:      &deck,
+		InfectionDeck: NewInfectionDeck(cities.CityNames()),
+		InfectionRate: 2,
+		DiseaseData:   []DiseaseData{blue, Black},
+	}
+
+	if prob := gs.ProbabilityOfOutbreak("a"); prob != 0.0 {
+		t.Errorf("Blue is eradicated, so a should not outbreak, got %v", prob)
+	}
+	if prob, drawn := gs.ProbabilityOfCube("b"), gs.ProbabilityOfCity("b"); math.Abs(prob-drawn) > 0.001 {
+		t.Errorf("Expected b to receive a cube only by being drawn, with probability %v, got %v", drawn, prob)
+	}
+	if prob := gs.ProbabilityOfCube("c"); prob != 0.0 {
+		t.Errorf("Blue is eradicated, so c should not receive a cube, got %v", prob)
+	}
+}
+
+func TestCureAndEradicate(t *testing.T) {
+	gs, err := NewGame("../data/new_game.json", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = gs.Infect("riyadh"); err != nil {
+		t.Fatal(err)
+	}
+	for _, card := range []CardName{"mumbai", "kolkata"} {
+		if err = gs.DrawCard(card); err != nil {
+			t.Fatal(err)
+		}
+	}
+	will, err := gs.GameTurns.GetPlayer("Will")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = gs.Cure(will, Red.Type); err == nil {
+		t.Fatal("Will should not have enough red cards to cure")
+	}
+	will.Location = "cairo"
+	if err = gs.Cure(will, Black.Type); err == nil {
+		t.Fatal("Cairo has no research station to cure at")
+	}
+	will.Location = StartCity
+	if err = gs.Cure(will, Black.Type); err != nil {
+		t.Fatal(err)
+	}
+	if len(will.Cards) != 0 {
+		t.Errorf("Expected Will to discard every black card, still has %v", len(will.Cards))
+	}
+	for _, dt := range gs.CurableDiseases() {
+		if dt == Black.Type {
+			t.Error("Black is cured and should no longer be curable")
+		}
+	}
+	if gs.IsEradicated(Black.Type) {
+		t.Fatal("Riyadh still has a cube, so black should not be eradicated")
+	}
+	if err = gs.Cure(will, Black.Type); err == nil {
+		t.Fatal("Should not be able to cure black twice")
+	}
+
+	if _, err = gs.Apply(Event{Type: InfectionLevelEvent, City: "riyadh", Value: 0}); err != nil {
+		t.Fatal(err)
+	}
+	if !gs.IsEradicated(Black.Type) {
+		t.Fatal("Expected black to be eradicated once its last cube was removed")
+	}
+	if prob := gs.ProbabilityOfCity("cairo"); prob != 0.0 {
+		t.Errorf("Eradicated diseases should not infect, got probability %v", prob)
+	}
+	if _, err = gs.Infect("cairo"); err != nil {
+		t.Fatal(err)
+	}
+	if cairo, _ := gs.GetCity("cairo"); cairo.NumInfections != 0 {
+		t.Errorf("Expected cairo to stay clean, got %v cubes", cairo.NumInfections)
+	}
+}
+
+func TestEpidemicAdvancesInfectionRate(t *testing.T) {
+	gs, err := NewGame("../data/new_game.json", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = gs.Infect("lagos"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = gs.Epidemic("lagos"); err == nil {
+		t.Fatal("Lagos was already drawn and is not on the bottom of the infection deck")
+	}
+	turn, _ := gs.GameTurns.CurrentTurn()
+	if gs.CityDeck.EpidemicsDrawn() != 0 || turn.Epidemics != 0 || gs.InfectionDeck.DrawnCount() != 1 {
+		t.Fatal("A failed epidemic should not change the game")
+	}
+
+	for i, city := range []CityName{"essen", "cairo", "tokyo"} {
+		if i == 2 {
+			gs.NextTurn(true)
+		}
+		if _, err = gs.Epidemic(city); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if gs.InfectionRate != 3 {
+		t.Fatalf("Expected an infection rate of 3 after 3 epidemics, got %v", gs.InfectionRate)
+	}
+	if err = gs.SetInfectionRate(5); err == nil {
+		t.Fatal("5 is not on the infection rate track")
+	}
+}
+
+func TestNewGameCopiesInfectionRateTrack(t *testing.T) {
+	gs, err := NewGame("../data/new_game.json", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs.InfectionRateTrack[0] = 9
+	if DefaultInfectionRateTrack[0] != 2 {
+		t.Fatal("Changing a game's infection rate track should not change the default track")
+	}
+}
+
+func TestHandLimit(t *testing.T) {
+	gs, err := NewGame("../data/new_game.json", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	will, _ := gs.GameTurns.GetPlayer("Will")
+	for _, player := range gs.GameTurns.PlayerOrder[1:] {
+		for _, card := range player.StartCards {
+			if err = gs.ExchangeCard(player, will, card); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if over := gs.PlayerOverHandLimit(); over != will {
+		t.Fatalf("Expected Will to be over the hand limit with %v cards", len(will.Cards))
+	}
+	if err = gs.DiscardCard(will, "essen"); err != nil {
+		t.Fatal(err)
+	}
+	if over := gs.PlayerOverHandLimit(); over != nil {
+		t.Fatalf("Did not expect %v to be over the hand limit", over.HumanName)
+	}
+	if len(gs.PlayerDiscard) != 1 || gs.PlayerDiscard[0].Card.Name() != "essen" || gs.PlayerDiscard[0].Player != "Will" {
+		t.Fatalf("Expected Will's essen on the player discard pile, got %+v", gs.PlayerDiscard)
+	}
+}
+
+func TestPlayEvent(t *testing.T) {
+	gs, err := NewGame("../data/new_game.json", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs.NextTurn(true)
+	macrae, _ := gs.GameTurns.GetPlayer("MacRae")
+	macrae.Cards = append(macrae.Cards, &CityCard{FundedEventName: "airlift"})
+
+	if holder := gs.CardHolder("airlift"); holder != macrae {
+		t.Fatalf("Expected MacRae to hold airlift, got %v", holder)
+	}
+	if err = gs.PlayEvent(macrae, "taipei"); err == nil {
+		t.Fatal("Taipei is not a funded event")
+	}
+	if err = gs.PlayEvent(macrae, "airlift"); err != nil {
+		t.Fatal(err)
+	}
+	if len(macrae.Cards) != 2 || len(gs.PlayerDiscard) != 0 {
+		t.Fatalf("Expected airlift to leave MacRae's hand without being discarded")
+	}
+	removed := gs.RemovedFromGame
+	if len(removed) != 1 || removed[0].Card.Name() != "airlift" || removed[0].Player != "MacRae" || removed[0].Turn != 1 {
+		t.Fatalf("Expected airlift to be removed from the game on turn 1, got %+v", removed)
+	}
+}
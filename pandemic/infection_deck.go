@@ -36,7 +36,7 @@ func (d *InfectionDeck) Draw(cityName CityName) error {
 		return fmt.Errorf("Card %v is not present in the active striation - how the fuck did you draw this card?", cityName)
 	}
 	d.Drawn.Add(cityName)
-	for d.Striations[0].Size() == 0 {
+	for len(d.Striations) > 0 && d.Striations[0].Size() == 0 {
 		d.Striations = d.Striations[1:]
 	}
 	return nil
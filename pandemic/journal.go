@@ -0,0 +1,296 @@
+package pandemic
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+const JournalFileName = "journal.jsonl"
+
+// A Journal is an append-only log of every Event applied to a game. Each
+// record is written as a line of JSON: the first record holds the state
+// the game started from, and the rest are events, undos and redos. The
+// current game state is rebuilt by replaying the events over the start
+// state, which is what makes undo possible.
+type Journal struct {
+	start  []byte
+	events []Event
+	undone *Stack // events taken back by Undo, most recent first
+	out    io.Writer
+}
+
+type journalRecord struct {
+	Start json.RawMessage `json:"start,omitempty"`
+	Event *Event          `json:"event,omitempty"`
+	Undo  bool            `json:"undo,omitempty"`
+	Redo  bool            `json:"redo,omitempty"`
+}
+
+// NewJournal starts a journal from the given game state. Records are
+// appended to out as they happen.
+func NewJournal(gs *GameState, out io.Writer) (*Journal, error) {
+	start, err := json.Marshal(gs)
+	if err != nil {
+		return nil, fmt.Errorf("Could not marshal gamestate as JSON: %v", err)
+	}
+	j := &Journal{
+		start:  start,
+		events: []Event{},
+		undone: NewStack(),
+		out:    out,
+	}
+	return j, j.write(journalRecord{Start: start})
+}
+
+// ReadJournal reads back every record from in and rebuilds the game state.
+// Further records are appended to out. A last record without its newline
+// was cut off by a crash before its command was reported done, so it is
+// dropped.
+func ReadJournal(in io.Reader, out io.Writer) (*Journal, *GameState, error) {
+	j, gs, _, err := readJournal(in, out)
+	return j, gs, err
+}
+
+// readJournal is ReadJournal, also returning how many bytes of in hold
+// complete records.
+func readJournal(in io.Reader, out io.Writer) (*Journal, *GameState, int64, error) {
+	j := &Journal{
+		events: []Event{},
+		undone: NewStack(),
+		out:    out,
+	}
+	var complete int64
+	reader := bufio.NewReader(in)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("Could not read journal: %v", err)
+		}
+		complete += int64(len(line))
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var record journalRecord
+		if err = json.Unmarshal(line, &record); err != nil {
+			return nil, nil, 0, fmt.Errorf("Invalid journal record: %v", err)
+		}
+		switch {
+		case record.Start != nil:
+			j.start = record.Start
+		case record.Event != nil:
+			j.events = append(j.events, *record.Event)
+			j.undone = NewStack()
+		case record.Undo:
+			if _, err := j.popEvent(); err != nil {
+				return nil, nil, 0, err
+			}
+		case record.Redo:
+			e, err := j.popUndone()
+			if err != nil {
+				return nil, nil, 0, err
+			}
+			j.events = append(j.events, e)
+		}
+	}
+	if j.start == nil {
+		return nil, nil, 0, fmt.Errorf("Journal has no starting game state")
+	}
+	gs, err := j.replay()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return j, gs, complete, nil
+}
+
+// CreateJournal starts a new journal file at path. It will not overwrite
+// an existing journal.
+func CreateJournal(path string, gs *GameState) (*Journal, error) {
+	fd, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("Could not create journal: %v", err)
+	}
+	return NewJournal(gs, fd)
+}
+
+// OpenJournal reads the journal file at path and continues appending to it.
+// A record cut off by a crash is removed first, so that the next record
+// starts on a line of its own.
+func OpenJournal(path string) (*Journal, *GameState, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	j, gs, complete, err := readJournal(in, out)
+	if err != nil {
+		out.Close()
+		return nil, nil, err
+	}
+	if err = out.Truncate(complete); err != nil {
+		out.Close()
+		return nil, nil, fmt.Errorf("Could not remove an unfinished journal record: %v", err)
+	}
+	return j, gs, nil
+}
+
+// Record applies the event to the game state and appends it to the journal.
+// If the event can't be applied, or its record can't be written, the game
+// state is rebuilt so that the event leaves no trace.
+func (j *Journal) Record(gs *GameState, e Event) (EventResult, error) {
+	result, err := gs.Apply(e)
+	if err != nil {
+		if rebuildErr := j.rebuild(gs); rebuildErr != nil {
+			return result, rebuildErr
+		}
+		return result, err
+	}
+	j.events = append(j.events, e)
+	undone := j.undone
+	j.undone = NewStack()
+	if err = j.write(journalRecord{Event: &e}); err != nil {
+		j.events = j.events[:len(j.events)-1]
+		j.undone = undone
+		return result, j.rollback(gs, err)
+	}
+	return result, nil
+}
+
+// Undo takes back the most recent event and rebuilds the game state
+// without it. The event that was undone is returned.
+func (j *Journal) Undo(gs *GameState) (Event, error) {
+	e, err := j.popEvent()
+	if err != nil {
+		return e, err
+	}
+	if err = j.rebuild(gs); err != nil {
+		j.unpopEvent(e)
+		return e, err
+	}
+	if err = j.write(journalRecord{Undo: true}); err != nil {
+		j.unpopEvent(e)
+		return e, j.rollback(gs, err)
+	}
+	return e, nil
+}
+
+// Redo re-applies the most recently undone event.
+func (j *Journal) Redo(gs *GameState) (Event, EventResult, error) {
+	e, err := j.popUndone()
+	if err != nil {
+		return e, EventResult{}, err
+	}
+	result, err := gs.Apply(e)
+	if err != nil {
+		j.undone.Push(e)
+		if rebuildErr := j.rebuild(gs); rebuildErr != nil {
+			return e, result, rebuildErr
+		}
+		return e, result, err
+	}
+	j.events = append(j.events, e)
+	if err = j.write(journalRecord{Redo: true}); err != nil {
+		j.events = j.events[:len(j.events)-1]
+		j.undone.Push(e)
+		return e, result, j.rollback(gs, err)
+	}
+	return e, result, nil
+}
+
+func (j *Journal) Events() []Event {
+	return j.events
+}
+
+func (j *Journal) Close() error {
+	if closer, ok := j.out.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (j *Journal) popEvent() (Event, error) {
+	if len(j.events) == 0 {
+		return Event{}, fmt.Errorf("Nothing to undo")
+	}
+	e := j.events[len(j.events)-1]
+	j.events = j.events[:len(j.events)-1]
+	j.undone.Push(e)
+	return e, nil
+}
+
+// unpopEvent puts back an event taken by popEvent when the undo fails.
+func (j *Journal) unpopEvent(e Event) {
+	j.undone.Pop()
+	j.events = append(j.events, e)
+}
+
+func (j *Journal) popUndone() (Event, error) {
+	head, err := j.undone.Pop()
+	if err != nil {
+		return Event{}, fmt.Errorf("Nothing to redo")
+	}
+	return head.(Event), nil
+}
+
+func (j *Journal) replay() (*GameState, error) {
+	gs, err := loadGameData(j.start)
+	if err != nil {
+		return nil, err
+	}
+	for i, e := range j.events {
+		if _, err := gs.Apply(e); err != nil {
+			return nil, fmt.Errorf("Could not replay event %v (%v): %v", i, e, err)
+		}
+	}
+	return gs, nil
+}
+
+// rebuild replaces the contents of gs in place, so that anything holding
+// on to the game state sees the rebuilt game.
+func (j *Journal) rebuild(gs *GameState) error {
+	rebuilt, err := j.replay()
+	if err != nil {
+		return err
+	}
+	*gs = *rebuilt
+	return nil
+}
+
+// rollback rebuilds the game state after a record could not be written, so
+// that the game in memory matches the journal on disk.
+func (j *Journal) rollback(gs *GameState, writeErr error) error {
+	if err := j.rebuild(gs); err != nil {
+		return err
+	}
+	return fmt.Errorf("Could not write to the journal: %v", writeErr)
+}
+
+func (j *Journal) write(record journalRecord) error {
+	if j.out == nil {
+		return nil
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err = j.out.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	// make sure the record is on disk before the command is reported done
+	if syncer, ok := j.out.(interface {
+		Sync() error
+	}); ok {
+		return syncer.Sync()
+	}
+	return nil
+}
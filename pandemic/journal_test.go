@@ -0,0 +1,251 @@
+package pandemic
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestJournal(t *testing.T) (*Journal, *GameState, *bytes.Buffer) {
+	gs, err := NewGame("../data/new_game.json", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	journal, err := NewJournal(gs, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return journal, gs, buf
+}
+
+func TestJournalUndoRedo(t *testing.T) {
+	journal, gs, _ := newTestJournal(t)
+
+	if _, err := journal.Record(gs, Event{Type: InfectEvent, City: "lagos"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := journal.Record(gs, Event{Type: DrawCardEvent, Card: "essen"}); err == nil {
+		t.Fatal("essen is in a starting hand and should not be drawable")
+	}
+	if _, err := journal.Record(gs, Event{Type: DrawCardEvent, Card: "lagos"}); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := journal.Undo(gs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Type != DrawCardEvent || e.Card != "lagos" {
+		t.Fatalf("Expected to undo drawing lagos, undid %v", e)
+	}
+	turn, _ := gs.GameTurns.CurrentTurn()
+	if len(turn.DrawnCards) != 0 || len(turn.Player.Cards) != 2 {
+		t.Fatalf("Expected no drawn cards after undo, had %v", len(turn.DrawnCards))
+	}
+	if turn.Player != gs.GameTurns.PlayerOrder[0] {
+		t.Fatal("Expected the current turn to point at the first player after rebuilding")
+	}
+	lagos, _ := gs.GetCity("lagos")
+	if lagos.NumInfections != 1 {
+		t.Fatalf("Expected lagos to still be infected after undoing the draw, had %v", lagos.NumInfections)
+	}
+
+	if _, _, err = journal.Redo(gs); err != nil {
+		t.Fatal(err)
+	}
+	turn, _ = gs.GameTurns.CurrentTurn()
+	if len(turn.DrawnCards) != 1 || len(turn.Player.Cards) != 3 {
+		t.Fatalf("Expected lagos to be drawn again after redo")
+	}
+	if _, _, err = journal.Redo(gs); err == nil {
+		t.Fatal("Expected nothing left to redo")
+	}
+}
+
+func TestReadJournal(t *testing.T) {
+	journal, gs, buf := newTestJournal(t)
+	events := []Event{
+		{Type: InfectEvent, City: "lagos"},
+		{Type: InfectEvent, City: "essen"},
+		{Type: NextTurnEvent, Force: true},
+		{Type: QuarantineEvent, City: "milan"},
+	}
+	for _, e := range events {
+		if _, err := journal.Record(gs, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := journal.Undo(gs); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := journal.Undo(gs); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := journal.Redo(gs); err != nil {
+		t.Fatal(err)
+	}
+
+	reread, rebuilt, err := ReadJournal(bytes.NewReader(buf.Bytes()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reread.Events()) != 3 {
+		t.Fatalf("Expected 3 events after replaying undos and redos, got %v", reread.Events())
+	}
+	if rebuilt.GameTurns.CurTurn != 1 {
+		t.Fatalf("Expected to be on the second turn, was on %v", rebuilt.GameTurns.CurTurn)
+	}
+	milan, _ := rebuilt.GetCity("milan")
+	if milan.Quarantined {
+		t.Fatal("The quarantine was undone and should not have been replayed")
+	}
+	if _, _, err := reread.Redo(rebuilt); err != nil {
+		t.Fatalf("The undone quarantine should still be available to redo: %v", err)
+	}
+}
+
+func TestJournalBatch(t *testing.T) {
+	journal, gs, buf := newTestJournal(t)
+	written := buf.Len()
+
+	bad := Event{Type: BatchEvent, Events: []Event{
+		{Type: InfectEvent, City: "lagos"},
+		{Type: InfectEvent, City: "lagos"},
+	}}
+	if _, err := journal.Record(gs, bad); err == nil {
+		t.Fatal("Expected a batch infecting lagos twice to fail")
+	}
+	if gs.InfectionDeck.Drawn.Contains(CityName("lagos")) || buf.Len() != written {
+		t.Fatal("Expected none of a failed batch to be applied or recorded")
+	}
+
+	batch := Event{Type: BatchEvent, Events: []Event{
+		{Type: InfectEvent, City: "lagos"},
+		{Type: InfectEvent, City: "khartoum"},
+	}}
+	if _, err := journal.Record(gs, batch); err != nil {
+		t.Fatal(err)
+	}
+	reread, rebuilt, err := ReadJournal(bytes.NewReader(buf.Bytes()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reread.Events()) != 1 || !rebuilt.InfectionDeck.Drawn.Contains(CityName("khartoum")) {
+		t.Fatalf("Expected the batch to be replayed as one event, got %v", reread.Events())
+	}
+
+	if _, err := journal.Undo(gs); err != nil {
+		t.Fatal(err)
+	}
+	if gs.InfectionDeck.Drawn.Contains(CityName("lagos")) || gs.InfectionDeck.Drawn.Contains(CityName("khartoum")) {
+		t.Fatal("Expected undo to take back the whole batch")
+	}
+}
+
+// brokenDisk accepts writes until it is told to fail.
+type brokenDisk struct {
+	bytes.Buffer
+	broken bool
+}
+
+func (d *brokenDisk) Write(p []byte) (int, error) {
+	if d.broken {
+		return 0, fmt.Errorf("disk full")
+	}
+	return d.Buffer.Write(p)
+}
+
+func TestJournalFailedWriteLeavesNoTrace(t *testing.T) {
+	gs, err := NewGame("../data/new_game.json", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	disk := &brokenDisk{}
+	journal, err := NewJournal(gs, disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := journal.Record(gs, Event{Type: InfectEvent, City: "essen"}); err != nil {
+		t.Fatal(err)
+	}
+
+	disk.broken = true
+	if _, err := journal.Record(gs, Event{Type: InfectEvent, City: "lagos"}); err == nil {
+		t.Fatal("Expected recording to fail when the journal can't be written")
+	}
+	if gs.InfectionDeck.Drawn.Contains(CityName("lagos")) || len(journal.Events()) != 1 {
+		t.Fatal("Expected a failed write to leave the game as it was")
+	}
+	if _, err := journal.Undo(gs); err == nil {
+		t.Fatal("Expected undo to fail when the journal can't be written")
+	}
+	if !gs.InfectionDeck.Drawn.Contains(CityName("essen")) || len(journal.Events()) != 1 {
+		t.Fatal("Expected a failed undo to leave the game as it was")
+	}
+
+	disk.broken = false
+	if _, err := journal.Undo(gs); err != nil {
+		t.Fatal(err)
+	}
+	disk.broken = true
+	if _, _, err := journal.Redo(gs); err == nil {
+		t.Fatal("Expected redo to fail when the journal can't be written")
+	}
+	if gs.InfectionDeck.Drawn.Contains(CityName("essen")) {
+		t.Fatal("Expected a failed redo to leave the game as it was")
+	}
+	disk.broken = false
+	if _, _, err := journal.Redo(gs); err != nil {
+		t.Fatalf("Expected the event to still be there to redo: %v", err)
+	}
+}
+
+func TestJournalTornLastRecord(t *testing.T) {
+	journal, gs, buf := newTestJournal(t)
+	for _, city := range []CityName{"lagos", "essen"} {
+		if _, err := journal.Record(gs, Event{Type: InfectEvent, City: city}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// cut the last record off half way, as a crash while writing would
+	torn := buf.Bytes()[:buf.Len()-10]
+
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, JournalFileName)
+	if err = ioutil.WriteFile(path, torn, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, rebuilt, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("Expected a journal with a torn last record to load: %v", err)
+	}
+	if len(reopened.Events()) != 1 || rebuilt.InfectionDeck.Drawn.Contains(CityName("essen")) {
+		t.Fatalf("Expected only the complete records to be replayed, got %v", reopened.Events())
+	}
+	if _, err = reopened.Record(rebuilt, Event{Type: InfectEvent, City: "tokyo"}); err != nil {
+		t.Fatal(err)
+	}
+	reopened.Close()
+
+	in, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	reread, _, err := ReadJournal(in, nil)
+	if err != nil {
+		t.Fatalf("Expected the journal to be readable after appending to it: %v", err)
+	}
+	if len(reread.Events()) != 2 {
+		t.Fatalf("Expected lagos and tokyo to be recorded, got %v", reread.Events())
+	}
+}
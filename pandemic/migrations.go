@@ -0,0 +1,234 @@
+package pandemic
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CurrentFormatVersion is the format_version written with every saved game.
+// Bump it whenever the shape of GameState changes, and register a
+// migration from the previous version.
+const CurrentFormatVersion = 3
+
+// A migration upgrades a saved game, decoded as generic JSON, from one
+// format version to the next.
+type migration func(game map[string]interface{}) error
+
+// migrations are keyed by the version they upgrade from.
+var migrations = map[int]migration{
+	0: migrateUnwrapCities,
+	1: migratePlayerIDs,
+	2: migrateStartStation,
+}
+
+// migrate upgrades a saved game to the current format version. Games saved
+// before format_version was added are recognized by their shape.
+func migrate(data []byte) ([]byte, error) {
+	var version struct {
+		FormatVersion int `json:"format_version"`
+	}
+	if err := json.Unmarshal(data, &version); err != nil {
+		return nil, err
+	}
+	if version.FormatVersion == CurrentFormatVersion {
+		return data, nil
+	}
+	if version.FormatVersion > CurrentFormatVersion {
+		return nil, fmt.Errorf("Saved game has format version %v, but only versions up to %v are supported. Is there a newer pandemic-nerd-hurd?", version.FormatVersion, CurrentFormatVersion)
+	}
+
+	var game map[string]interface{}
+	if err := json.Unmarshal(data, &game); err != nil {
+		return nil, err
+	}
+	from := version.FormatVersion
+	if from == 0 {
+		from = unversionedFormat(game)
+	}
+	for ; from < CurrentFormatVersion; from++ {
+		upgrade, ok := migrations[from]
+		if !ok {
+			return nil, fmt.Errorf("No migration from save format version %v", from)
+		}
+		if err := upgrade(game); err != nil {
+			return nil, fmt.Errorf("Could not migrate saved game from format version %v: %v", from, err)
+		}
+	}
+	game["format_version"] = CurrentFormatVersion
+	return json.Marshal(game)
+}
+
+// Games saved before format_version was added come in two shapes: the
+// earliest ones (May) wrap the cities in an object and have no city cards
+// or players, and the rest are version 1.
+func unversionedFormat(game map[string]interface{}) int {
+	if _, ok := game["cities"].(map[string]interface{}); ok {
+		return 0
+	}
+	return 1
+}
+
+// Version 0 wrapped the cities as {"cities": [...]}, had no original
+// disease for faded cities, only recorded the size of the city deck, and
+// had no players. The deck is rebuilt with nothing drawn, since the cards
+// drawn were never saved, and the game is given no players rather than
+// none at all.
+func migrateUnwrapCities(game map[string]interface{}) error {
+	wrapped, _ := game["cities"].(map[string]interface{})
+	list, ok := wrapped["cities"].([]interface{})
+	if !ok {
+		return fmt.Errorf("Expected a list of cities")
+	}
+	for _, entry := range list {
+		city, ok := entry.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("Expected each city to be an object")
+		}
+		if _, ok := city["original_disease"]; !ok {
+			city["original_disease"] = city["disease"]
+		}
+	}
+	game["cities"] = list
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	var cities Cities
+	if err = json.Unmarshal(data, &cities); err != nil {
+		return err
+	}
+	deck, err := cities.GenerateCityDeck(EpidemicsPerGame, []*FundedEvent{}, Set{})
+	if err != nil {
+		return err
+	}
+	if _, ok := game["game_turns"]; !ok {
+		if err = replaceWithJSON(game, "game_turns", InitGameTurns()); err != nil {
+			return err
+		}
+	}
+	return replaceWithJSON(game, "city_deck", deck)
+}
+
+// Version 1 saved each turn with a full copy of its player, player fields
+// without JSON tags, and epidemics without numbers. Players are given IDs
+// in turn order, and turns refer to them by ID.
+func migratePlayerIDs(game map[string]interface{}) error {
+	if deck, ok := game["city_deck"].(map[string]interface{}); ok {
+		numberEpidemics(deck["All"])
+		numberEpidemics(deck["Drawn"])
+	}
+
+	turns, ok := game["game_turns"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	ids := map[string]int{}
+	players, _ := turns["player_order"].([]interface{})
+	for i, entry := range players {
+		player, ok := entry.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("Expected each player to be an object")
+		}
+		renameKey(player, "Location", "location")
+		renameKey(player, "Cards", "cards")
+		if _, ok := player["id"]; !ok {
+			player["id"] = i + 1
+		}
+		name, _ := player["human_name"].(string)
+		ids[name] = i + 1
+	}
+
+	list, _ := turns["turns"].([]interface{})
+	for i, entry := range list {
+		turn, ok := entry.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("Expected each turn to be an object")
+		}
+		player, ok := turn["player"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := player["human_name"].(string)
+		id, ok := ids[name]
+		if !ok {
+			return fmt.Errorf("Turn %v belongs to %q, who is not in the player order", i+1, name)
+		}
+		delete(turn, "player")
+		turn["player_id"] = id
+	}
+	return nil
+}
+
+// Games started before research stations and player locations were tracked
+// have neither, so nobody could ever cure in them. They are given the
+// research station in Atlanta that every game starts with, and players
+// with no location are placed there.
+func migrateStartStation(game map[string]interface{}) error {
+	cities, _ := game["cities"].([]interface{})
+	var atlanta map[string]interface{}
+	for _, entry := range cities {
+		city, ok := entry.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("Expected each city to be an object")
+		}
+		if city["research_station"] == true {
+			return nil
+		}
+		if city["name"] == string(StartCity) {
+			atlanta = city
+		}
+	}
+	if atlanta == nil {
+		return nil
+	}
+	atlanta["research_station"] = true
+
+	turns, _ := game["game_turns"].(map[string]interface{})
+	players, _ := turns["player_order"].([]interface{})
+	for _, entry := range players {
+		player, ok := entry.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("Expected each player to be an object")
+		}
+		if location, _ := player["location"].(string); location == "" {
+			player["location"] = string(StartCity)
+		}
+	}
+	return nil
+}
+
+func numberEpidemics(cards interface{}) {
+	list, _ := cards.([]interface{})
+	number := 1
+	for _, entry := range list {
+		card, ok := entry.(map[string]interface{})
+		if !ok || card["is_epidemic"] != true {
+			continue
+		}
+		card["epidemic_number"] = number
+		number++
+	}
+}
+
+func renameKey(object map[string]interface{}, from, to string) {
+	if value, ok := object[from]; ok {
+		delete(object, from)
+		object[to] = value
+	}
+}
+
+// replaceWithJSON stores value in the generic game under key, in the same
+// form it would take if it had been unmarshaled from a save.
+func replaceWithJSON(game map[string]interface{}, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var generic interface{}
+	if err = json.Unmarshal(data, &generic); err != nil {
+		return err
+	}
+	game[key] = generic
+	return nil
+}
@@ -0,0 +1,130 @@
+package pandemic
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMigrateWrappedCities(t *testing.T) {
+	data := `{
+		"cities": {"cities": [
+			{"name": "a", "disease": "Blue", "panic_level": "Nothing", "neighbors": ["b"], "num_infections": 2},
+			{"name": "b", "disease": "Faded", "panic_level": "Unstable", "neighbors": ["a"], "num_infections": 0}
+		]},
+		"city_deck": {"Drawn": null, "Total": 2},
+		"disease_data": [{"type": "Blue"}],
+		"infection_deck": {"Drawn": {"a": {}}, "Striations": [{"b": {}}]},
+		"infection_rate": 2,
+		"outbreaks": 1,
+		"game_name": "may"
+	}`
+	gs, err := loadGameData([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gs.FormatVersion != CurrentFormatVersion {
+		t.Errorf("Expected format version %v, got %v", CurrentFormatVersion, gs.FormatVersion)
+	}
+	b, err := gs.GetCity("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.OriginalDisease != Faded.Type || b.PanicLevel != Unstable {
+		t.Errorf("Unexpected city after migration: %+v", b)
+	}
+	if total := gs.CityDeck.Total(); total != 2+EpidemicsPerGame {
+		t.Errorf("Expected a rebuilt deck of %v cards, got %v", 2+EpidemicsPerGame, total)
+	}
+	if gs.GameTurns == nil || len(gs.GameTurns.PlayerOrder) != 0 {
+		t.Error("May games did not track players, so should have none")
+	}
+	if _, err = gs.GameTurns.CurrentTurn(); err == nil {
+		t.Error("Expected a game with no players to have no current turn")
+	}
+}
+
+func TestMigratePlayerIDs(t *testing.T) {
+	data := `{
+		"cities": [{"name": "a", "disease": "Blue", "original_disease": "Blue", "neighbors": []}],
+		"city_deck": {
+			"All": [{"city_name": "a", "is_epidemic": false}, {"is_epidemic": true}, {"is_epidemic": true}],
+			"Drawn": [{"is_epidemic": true}],
+			"StartCities": []
+		},
+		"infection_deck": {"Drawn": {}, "Striations": [{"a": {}}]},
+		"game_turns": {
+			"cur_turn": 1,
+			"player_order": [
+				{"human_name": "Will", "Location": "a", "Cards": [{"city_name": "a", "is_epidemic": false}]},
+				{"human_name": "MacRae", "Location": "", "Cards": null}
+			],
+			"turns": [
+				{"player": {"human_name": "Will"}, "drawn_cards": []},
+				{"player": {"human_name": "MacRae"}, "drawn_cards": []}
+			]
+		}
+	}`
+	gs, err := loadGameData([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	turn, err := gs.GameTurns.CurrentTurn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if turn.Player != gs.GameTurns.PlayerOrder[1] || turn.Player.ID != 2 {
+		t.Fatalf("Expected MacRae's turn with ID 2, got %+v", turn.Player)
+	}
+	will := gs.GameTurns.PlayerOrder[0]
+	if will.Location != "a" || len(will.Cards) != 1 {
+		t.Errorf("Expected Will's location and cards to survive migration, got %+v", will)
+	}
+	if name := gs.CityDeck.Drawn[0].Name(); name != "epidemic-1" {
+		t.Errorf("Expected the drawn epidemic to be numbered, got %v", name)
+	}
+	if gs.CityDeck.Drawn[0] != gs.CityDeck.All[1] {
+		t.Error("Expected the drawn epidemic to be the first epidemic in the deck")
+	}
+}
+
+func TestLoadFutureFormatVersion(t *testing.T) {
+	_, err := loadGameData([]byte(`{"format_version": 99}`))
+	if err == nil || !strings.Contains(err.Error(), "format version 99") {
+		t.Fatalf("Expected a clear error for an unknown format version, got %v", err)
+	}
+}
+
+func TestMigrateStartStation(t *testing.T) {
+	card := func(city string) string {
+		return `{"city_name": "` + city + `", "is_epidemic": false}`
+	}
+	data := `{
+		"format_version": 2,
+		"cities": [
+			{"name": "atlanta", "disease": "Blue", "original_disease": "Blue", "neighbors": []},
+			{"name": "a", "disease": "Blue", "original_disease": "Blue", "neighbors": []},
+			{"name": "b", "disease": "Blue", "original_disease": "Blue", "neighbors": []},
+			{"name": "c", "disease": "Blue", "original_disease": "Blue", "neighbors": []},
+			{"name": "d", "disease": "Blue", "original_disease": "Blue", "neighbors": []}
+		],
+		"city_deck": {"All": [` + card("atlanta") + `, ` + card("a") + `, ` + card("b") + `, ` + card("c") + `, ` + card("d") + `], "Drawn": []},
+		"disease_data": [{"type": "Blue"}],
+		"infection_deck": {"Drawn": {}, "Striations": [{"a": {}}]},
+		"game_turns": {
+			"cur_turn": 0,
+			"player_order": [{"id": 1, "human_name": "Will", "location": "", "cards": [` + card("atlanta") + `, ` + card("a") + `, ` + card("b") + `, ` + card("c") + `, ` + card("d") + `]}],
+			"turns": [{"player_id": 1, "drawn_cards": []}]
+		}
+	}`
+	gs, err := loadGameData([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	will := gs.GameTurns.PlayerOrder[0]
+	if will.Location != StartCity {
+		t.Fatalf("Expected Will to be placed in %v, was in %q", StartCity, will.Location)
+	}
+	if err := gs.Cure(will, Blue.Type); err != nil {
+		t.Fatalf("Expected curing to work in a migrated game: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package pandemic
+
+import (
+	"fmt"
+)
+
+// Every player starts the game in Atlanta.
+const StartCity = CityName("atlanta")
+
+// Drive moves the player to a city connected to the one they are in. A
+// player whose location isn't known, which only happens in old games with
+// no Atlanta to start from, can be placed in any city.
+func (gs *GameState) Drive(player *Player, to CityName) error {
+	dest, err := gs.moveDestination(player, to)
+	if err != nil {
+		return err
+	}
+	if !player.Location.Empty() && !dest.IsNeighbor(player.Location) {
+		return fmt.Errorf("%v is not connected to %v", to, player.Location)
+	}
+	player.Location = to
+	return nil
+}
+
+// DirectFlight moves the player to any city by discarding that city's card.
+func (gs *GameState) DirectFlight(player *Player, to CityName) error {
+	if _, err := gs.moveDestination(player, to); err != nil {
+		return err
+	}
+	if err := gs.DiscardCard(player, to.CardName()); err != nil {
+		return err
+	}
+	player.Location = to
+	return nil
+}
+
+// CharterFlight moves the player to any city by discarding the card of the
+// city they are in.
+func (gs *GameState) CharterFlight(player *Player, to CityName) error {
+	if _, err := gs.moveDestination(player, to); err != nil {
+		return err
+	}
+	if player.Location.Empty() {
+		return fmt.Errorf("%v's location is unknown, so they have no card to charter a flight with", player.HumanName)
+	}
+	if err := gs.DiscardCard(player, player.Location.CardName()); err != nil {
+		return err
+	}
+	player.Location = to
+	return nil
+}
+
+// ShuttleFlight moves the player between two cities with research stations.
+func (gs *GameState) ShuttleFlight(player *Player, to CityName) error {
+	if _, err := gs.moveDestination(player, to); err != nil {
+		return err
+	}
+	if !gs.hasResearchStation(player.Location) {
+		return fmt.Errorf("%v is not at a research station", player.HumanName)
+	}
+	if !gs.hasResearchStation(to) {
+		return fmt.Errorf("%v has no research station", to)
+	}
+	player.Location = to
+	return nil
+}
+
+func (gs *GameState) moveDestination(player *Player, to CityName) (*City, error) {
+	dest, err := gs.Cities.GetCity(to)
+	if err != nil {
+		return nil, err
+	}
+	if player.Location == to {
+		return nil, fmt.Errorf("%v is already in %v", player.HumanName, to)
+	}
+	return dest, nil
+}
@@ -0,0 +1,106 @@
+package pandemic
+
+import (
+	"testing"
+)
+
+func TestMovement(t *testing.T) {
+	gs, err := NewGame("../data/new_game.json", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	will, err := gs.GameTurns.GetPlayer("Will")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if will.Location != StartCity {
+		t.Fatalf("Expected Will to start in %v, got %v", StartCity, will.Location)
+	}
+
+	if err = gs.Drive(will, "london"); err == nil {
+		t.Fatal("Atlanta is not connected to london")
+	}
+	if err = gs.Drive(will, "washington"); err != nil {
+		t.Fatal(err)
+	}
+	if err = gs.DirectFlight(will, "tokyo"); err == nil {
+		t.Fatal("Will does not hold the tokyo card")
+	}
+	if err = gs.DirectFlight(will, "delhi"); err != nil {
+		t.Fatal(err)
+	}
+	if err = gs.CharterFlight(will, "lagos"); err == nil {
+		t.Fatal("Will discarded delhi to get there, so cannot charter a flight out of it")
+	}
+	if err = gs.Drive(will, "chennai"); err != nil {
+		t.Fatal(err)
+	}
+	if err = gs.CharterFlight(will, "lagos"); err != nil {
+		t.Fatal(err)
+	}
+	if will.Location != "lagos" || len(will.Cards) != 0 {
+		t.Fatalf("Expected Will in lagos with no cards, got %v with %v cards", will.Location, len(will.Cards))
+	}
+}
+
+func TestQuarantineSpecialistNeedsLocation(t *testing.T) {
+	gs, err := NewGame("../data/new_game.json", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = gs.Apply(Event{Type: QuarantineEvent, City: "taipei"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = gs.Apply(Event{Type: DirectFlightEvent, Player: "MacRae", City: "taipei"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = gs.Infect("taipei"); err != nil {
+		t.Fatal(err)
+	}
+	taipei, _ := gs.GetCity("taipei")
+	if !taipei.Quarantined || taipei.NumInfections != 0 {
+		t.Fatalf("Expected the Quarantine Specialist to keep taipei quarantined and clean, got %+v", taipei)
+	}
+}
+
+func TestResearchStations(t *testing.T) {
+	gs, err := NewGame("../data/new_game.json", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	will, err := gs.GameTurns.GetPlayer("Will")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = gs.ShuttleFlight(will, "cairo"); err == nil {
+		t.Fatal("Cairo has no research station to shuttle to")
+	}
+	if err = gs.BuildResearchStation(StartCity); err == nil {
+		t.Fatal("Atlanta starts with a research station")
+	}
+
+	cairo, _ := gs.GetCity("cairo")
+	cairo.PanicLevel = Rioting2
+	if err = gs.BuildResearchStation("cairo"); err == nil {
+		t.Fatal("Rioting cities cannot build")
+	}
+	if err = gs.BuildMilitaryBase("cairo"); err == nil {
+		t.Fatal("Rioting cities cannot build")
+	}
+	cairo.PanicLevel = Unstable
+	if err = gs.BuildResearchStation("cairo"); err != nil {
+		t.Fatal(err)
+	}
+	if err = gs.ShuttleFlight(will, "cairo"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, city := range []CityName{"lagos", "tokyo", "lima", "paris"} {
+		if err = gs.BuildResearchStation(city); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = gs.BuildResearchStation("sydney"); err == nil {
+		t.Fatalf("Expected the station limit of %v to be enforced", MaxResearchStations)
+	}
+}
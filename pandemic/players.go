@@ -21,25 +21,35 @@ const (
 )
 
 type Player struct {
-	HumanName  string     `json:"human_name"`
-	Character  *Character `json:"character"`
-	Location   CityName
-	StartCards []CardName `json:"start_cards"`
-	Cards      []*CityCard
+	ID         int         `json:"id"`
+	HumanName  string      `json:"human_name"`
+	Character  *Character  `json:"character"`
+	Location   CityName    `json:"location,omitempty"`
+	StartCards []CardName  `json:"start_cards"`
+	Cards      []*CityCard `json:"cards"`
 }
 
-func (p *Player) Discard(cardName CardName) error {
+// Discard takes the card out of the player's hand and returns it. Use
+// GameState.DiscardCard to put it on the player discard pile.
+func (p *Player) Discard(cardName CardName) (*CityCard, error) {
 	filtered := []*CityCard{}
+	var discarded *CityCard
 	for _, card := range p.Cards {
 		if card.Name() != cardName {
 			filtered = append(filtered, card)
+		} else {
+			discarded = card
 		}
 	}
-	if len(filtered) == len(p.Cards) {
-		return fmt.Errorf("%v does not seem to have %v\n", p.HumanName, cardName)
+	if discarded == nil {
+		return nil, fmt.Errorf("%v does not seem to have %v\n", p.HumanName, cardName)
 	}
 	p.Cards = filtered
-	return nil
+	return discarded, nil
+}
+
+func (p *Player) OverHandLimit() bool {
+	return len(p.Cards) > HandLimit
 }
 
 type Character struct {
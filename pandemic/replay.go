@@ -0,0 +1,295 @@
+package pandemic
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Snapshots are written as game_<unix nanos>_<command>.json. Saving twice
+// in the same nanosecond appends a -N to the command.
+var snapshotPattern = regexp.MustCompile(`^game_(\d+)_([a-z-]+?)(-\d+)?\.json$`)
+
+// The console accepts short aliases for commands, and the snapshot file
+// name records whichever form was typed.
+var commandAliases = map[string]string{
+	"i":  "infect",
+	"n":  "next-turn",
+	"g":  "give-card",
+	"e":  "epidemic",
+	"r":  "infect-rate",
+	"l":  "city-infect-level",
+	"c":  "city-draw",
+	"q":  "quarantine",
+	"d":  "discard",
+	"rq": "remove-quarantine",
+}
+
+// A ReplayStep is a single saved snapshot of a game, along with what
+// changed since the previous snapshot.
+type ReplayStep struct {
+	File      string
+	Timestamp int64
+	Command   string
+	State     *GameState
+	LoadError error
+	Changes   []string
+	Problems  []string
+}
+
+// Summary describes the command that produced this step, filling in its
+// argument from the state diff where one can be inferred.
+func (s *ReplayStep) Summary() string {
+	if s.LoadError != nil {
+		return fmt.Sprintf("%v (unreadable)", s.Command)
+	}
+	if len(s.Changes) == 0 {
+		return fmt.Sprintf("%v (no change)", s.Command)
+	}
+	return fmt.Sprintf("%v: %v", s.Command, s.Changes[0])
+}
+
+// LoadReplay reads every snapshot in dir, orders them by timestamp and
+// compares each one with the step before it.
+func LoadReplay(dir string) ([]*ReplayStep, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	steps := []*ReplayStep{}
+	for _, file := range files {
+		step, ok := snapshotStep(filepath.Join(dir, file.Name()))
+		if !ok {
+			continue
+		}
+		step.State, step.LoadError = LoadGame(step.File)
+		steps = append(steps, step)
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("No game snapshots found in %v", dir)
+	}
+	sort.Sort(byTimestamp(steps))
+
+	checker := newReplayChecker()
+	var prev *GameState
+	for _, step := range steps {
+		if step.State == nil {
+			continue
+		}
+		if prev == nil {
+			step.Changes = []string{"first readable snapshot"}
+		} else {
+			step.Changes = diffStates(prev, step.State)
+		}
+		step.Problems = checker.check(prev, step.State)
+		prev = step.State
+	}
+	return steps, nil
+}
+
+// snapshotStep reads the timestamp and command out of a snapshot's file
+// name, without loading the snapshot itself.
+func snapshotStep(path string) (*ReplayStep, bool) {
+	match := snapshotPattern.FindStringSubmatch(filepath.Base(path))
+	if match == nil {
+		return nil, false
+	}
+	timestamp, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	command := match[2]
+	if long, ok := commandAliases[command]; ok {
+		command = long
+	}
+	return &ReplayStep{
+		File:      path,
+		Timestamp: timestamp,
+		Command:   command,
+	}, true
+}
+
+type byTimestamp []*ReplayStep
+
+func (b byTimestamp) Len() int { return len(b) }
+
+func (b byTimestamp) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+
+func (b byTimestamp) Less(i, j int) bool {
+	if b[i].Timestamp != b[j].Timestamp {
+		return b[i].Timestamp < b[j].Timestamp
+	}
+	return b[i].File < b[j].File
+}
+
+// diffStates lists the differences between two snapshots of the same game
+// in terms of the commands that could have caused them.
+func diffStates(prev, cur *GameState) []string {
+	changes := []string{}
+
+	if cur.InfectionDeck != nil && prev.InfectionDeck != nil {
+		if wasShuffled(prev, cur) {
+			bottom := prev.InfectionDeck.BottomStriation()
+			for _, name := range bottom.Members() {
+				if !cur.InfectionDeck.BottomStriation().Contains(CityName(name)) {
+					changes = append(changes, fmt.Sprintf("epidemic in %v", name))
+				}
+			}
+			changes = append(changes, "infection discard pile shuffled onto the deck")
+		} else {
+			for _, name := range cur.InfectionDeck.Drawn.Members() {
+				if !prev.InfectionDeck.Drawn.Contains(CityName(name)) {
+					changes = append(changes, fmt.Sprintf("infection card %v drawn", name))
+				}
+			}
+		}
+	}
+
+	if cur.CityDeck != nil && prev.CityDeck != nil && len(cur.CityDeck.Drawn) > len(prev.CityDeck.Drawn) {
+		for _, card := range cur.CityDeck.Drawn[len(prev.CityDeck.Drawn):] {
+			changes = append(changes, fmt.Sprintf("city card %v drawn", card.Name()))
+		}
+	}
+
+	if cur.Cities != nil && prev.Cities != nil {
+		for _, city := range *cur.Cities {
+			before, err := prev.Cities.GetCity(city.Name)
+			if err != nil {
+				continue
+			}
+			if before.NumInfections != city.NumInfections {
+				changes = append(changes, fmt.Sprintf("%v cubes %v -> %v", city.Name, before.NumInfections, city.NumInfections))
+			}
+			if before.Quarantined != city.Quarantined {
+				if city.Quarantined {
+					changes = append(changes, fmt.Sprintf("%v quarantined", city.Name))
+				} else {
+					changes = append(changes, fmt.Sprintf("%v quarantine removed", city.Name))
+				}
+			}
+		}
+	}
+
+	if cur.InfectionRate != prev.InfectionRate {
+		changes = append(changes, fmt.Sprintf("infection rate %v -> %v", prev.InfectionRate, cur.InfectionRate))
+	}
+	if cur.Outbreaks != prev.Outbreaks {
+		changes = append(changes, fmt.Sprintf("outbreaks %v -> %v", prev.Outbreaks, cur.Outbreaks))
+	}
+
+	if cur.GameTurns != nil && prev.GameTurns != nil {
+		if cur.GameTurns.CurTurn != prev.GameTurns.CurTurn {
+			if turn, err := cur.GameTurns.CurrentTurn(); err == nil {
+				changes = append(changes, fmt.Sprintf("%v's turn", turn.Player.HumanName))
+			}
+		}
+		for _, player := range cur.GameTurns.PlayerOrder {
+			before, err := prev.GameTurns.GetPlayer(player.HumanName)
+			if err != nil {
+				continue
+			}
+			gained, lost := diffHands(before.Cards, player.Cards)
+			for _, card := range gained {
+				changes = append(changes, fmt.Sprintf("%v gained %v", player.HumanName, card))
+			}
+			for _, card := range lost {
+				changes = append(changes, fmt.Sprintf("%v lost %v", player.HumanName, card))
+			}
+		}
+	}
+	return changes
+}
+
+// An intensify puts every drawn infection card back on top of the deck.
+func wasShuffled(prev, cur *GameState) bool {
+	if cur.InfectionDeck.DrawnCount() >= prev.InfectionDeck.DrawnCount() {
+		return false
+	}
+	for _, name := range prev.InfectionDeck.Drawn.Members() {
+		if !cur.InfectionDeck.TopStriation().Contains(CityName(name)) {
+			return false
+		}
+	}
+	return true
+}
+
+func diffHands(before, after []*CityCard) (gained []CardName, lost []CardName) {
+	beforeSet := Set{}
+	for _, card := range before {
+		beforeSet.Add(card.Name())
+	}
+	afterSet := Set{}
+	for _, card := range after {
+		afterSet.Add(card.Name())
+		if !beforeSet.Contains(card.Name()) {
+			gained = append(gained, card.Name())
+		}
+	}
+	for _, card := range before {
+		if !afterSet.Contains(card.Name()) {
+			lost = append(lost, card.Name())
+		}
+	}
+	return gained, lost
+}
+
+// replayChecker remembers enough about the steps it has already seen to
+// spot snapshots that break the rules of the game.
+type replayChecker struct {
+	drawnSinceShuffle Set
+}
+
+func newReplayChecker() *replayChecker {
+	return &replayChecker{Set{}}
+}
+
+func (r *replayChecker) check(prev, cur *GameState) []string {
+	problems := []string{}
+
+	if cur.InfectionDeck != nil {
+		if prev != nil && prev.InfectionDeck != nil && wasShuffled(prev, cur) {
+			r.drawnSinceShuffle = Set{}
+		}
+		for _, name := range cur.InfectionDeck.Drawn.Members() {
+			city := CityName(name)
+			if prev != nil && prev.InfectionDeck != nil && prev.InfectionDeck.Drawn.Contains(city) {
+				continue
+			}
+			if r.drawnSinceShuffle.Contains(city) {
+				problems = append(problems, fmt.Sprintf("infection card %v drawn twice before a shuffle", name))
+			}
+			r.drawnSinceShuffle.Add(city)
+		}
+	}
+
+	if cur.CityDeck != nil {
+		seen := Set{}
+		for _, card := range cur.CityDeck.Drawn {
+			if !card.IsEpidemic && seen.Contains(card.Name()) {
+				problems = append(problems, fmt.Sprintf("city card %v drawn more than once", card.Name()))
+			}
+			seen.Add(card.Name())
+		}
+		if numEpidemics := cur.CityDeck.NumEpidemics(); cur.CityDeck.EpidemicsDrawn() > numEpidemics {
+			problems = append(problems, fmt.Sprintf("%v epidemics drawn from a deck with %v", cur.CityDeck.EpidemicsDrawn(), numEpidemics))
+		}
+	}
+
+	if cur.Cities != nil {
+		for _, city := range *cur.Cities {
+			if city.NumInfections < 0 || city.NumInfections > 3 {
+				problems = append(problems, fmt.Sprintf("%v has %v cubes", city.Name, city.NumInfections))
+			}
+		}
+	}
+
+	if cur.GameTurns != nil {
+		if turn, err := cur.GameTurns.CurrentTurn(); err == nil && len(turn.DrawnCards) > CityCardsPerTurn {
+			problems = append(problems, fmt.Sprintf("%v drew %v city cards in one turn", turn.Player.HumanName, len(turn.DrawnCards)))
+		}
+	}
+	return problems
+}
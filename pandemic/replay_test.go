@@ -0,0 +1,83 @@
+package pandemic
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeSnapshot(t *testing.T, dir string, timestamp int, cmd string, gs *GameState) {
+	data, err := json.Marshal(gs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(dir, fmt.Sprintf("game_%v_%v.json", timestamp, cmd))
+	if err = ioutil.WriteFile(filename, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	gs, err := NewGame("../data/new_game.json", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = gs.Infect("lagos"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = gs.Infect("essen"); err != nil {
+		t.Fatal(err)
+	}
+	writeSnapshot(t, dir, 100, "i", gs)
+	if err = gs.DrawCard("milan"); err != nil {
+		t.Fatal(err)
+	}
+	writeSnapshot(t, dir, 200, "c", gs)
+
+	// Put lagos back on top of the infection deck without a shuffle,
+	// so that drawing it again breaks the rules.
+	gs.InfectionDeck.Drawn.Remove(CityName("lagos"))
+	gs.InfectionDeck.Striations[0].Add(CityName("lagos"))
+	writeSnapshot(t, dir, 300, "l", gs)
+	if _, err = gs.Infect("lagos"); err != nil {
+		t.Fatal(err)
+	}
+	writeSnapshot(t, dir, 400, "infect", gs)
+	if err = ioutil.WriteFile(filepath.Join(dir, "game_50_n.json"), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	steps, err := LoadReplay(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 5 {
+		t.Fatalf("Expected 5 steps, got %v", len(steps))
+	}
+	if steps[0].LoadError == nil || steps[0].Command != "next-turn" {
+		t.Errorf("Expected the first step to be an unreadable next-turn, got %v", steps[0].Summary())
+	}
+	if summary := steps[2].Summary(); summary != "city-draw: city card milan drawn" {
+		t.Errorf("Unexpected summary for the city draw: %v", summary)
+	}
+	for i, step := range steps[1:4] {
+		if len(step.Problems) != 0 {
+			t.Errorf("Did not expect problems in step %v: %v", i+1, step.Problems)
+		}
+	}
+	if summary := steps[4].Summary(); summary != "infect: infection card lagos drawn" {
+		t.Errorf("Unexpected summary for the second lagos infection: %v", summary)
+	}
+	if len(steps[4].Problems) != 1 {
+		t.Fatalf("Expected lagos being drawn twice to be flagged, got %v", steps[4].Problems)
+	}
+}
@@ -0,0 +1,123 @@
+package pandemic
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// A savedGame wraps a snapshot of the game with a checksum of its JSON,
+// so that a snapshot cut short by a crash is noticed when it is loaded.
+type savedGame struct {
+	Checksum string          `json:"checksum"`
+	Game     json.RawMessage `json:"game"`
+}
+
+// SnapshotFileName names a snapshot taken after the given command, in the
+// form LoadReplay and LatestGame expect.
+func SnapshotFileName(command string, at time.Time) string {
+	return fmt.Sprintf("game_%v_%v.json", at.UnixNano(), command)
+}
+
+// SaveGame writes a snapshot of the game to path. The snapshot is written
+// to a temporary file in the same folder, synced to disk and then renamed
+// into place, so path either holds the old contents or the complete new
+// snapshot.
+func SaveGame(path string, gs *GameState) error {
+	game, err := json.Marshal(gs)
+	if err != nil {
+		return fmt.Errorf("Could not marshal gamestate as JSON: %v", err)
+	}
+	data, err := json.Marshal(savedGame{checksum(game), game})
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("Could not create a temporary save file: %v", err)
+	}
+	defer os.Remove(tmp.Name()) // a no-op once renamed
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// sync the folder too, so that the rename itself survives a crash
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// unwrapSave checks the checksum of a snapshot written by SaveGame and
+// returns the game inside it. Snapshots from before checksums were added
+// are returned as they are.
+func unwrapSave(data []byte) ([]byte, error) {
+	var saved savedGame
+	if err := json.Unmarshal(data, &saved); err != nil || len(saved.Game) == 0 {
+		return data, nil
+	}
+	if checksum(saved.Game) != saved.Checksum {
+		return nil, fmt.Errorf("Saved game is corrupt: checksum does not match")
+	}
+	return saved.Game, nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// LatestGame loads the newest snapshot in dir that can be read, skipping
+// over any that are corrupt. The path of the snapshot loaded is returned
+// along with the game.
+func LatestGame(dir string) (*GameState, string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	steps := []*ReplayStep{}
+	for _, file := range files {
+		if step, ok := snapshotStep(filepath.Join(dir, file.Name())); ok {
+			steps = append(steps, step)
+		}
+	}
+	gs, path := loadNewest(steps)
+	if gs == nil {
+		return nil, "", fmt.Errorf("No readable snapshots found in %v", dir)
+	}
+	return gs, path, nil
+}
+
+// loadNewest loads the newest of the snapshots that can be read.
+func loadNewest(steps []*ReplayStep) (*GameState, string) {
+	sort.Sort(sort.Reverse(byTimestamp(steps)))
+	for _, step := range steps {
+		if gs, err := LoadGame(step.File); err == nil {
+			return gs, step.File
+		}
+	}
+	return nil, ""
+}
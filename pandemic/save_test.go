@@ -0,0 +1,107 @@
+package pandemic
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveGameRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "save")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	gs, err := NewGame("../data/new_game.json", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = gs.Infect("lagos"); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, SnapshotFileName("infect", time.Unix(0, 100)))
+	if err = SaveGame(path, gs); err != nil {
+		t.Fatal(err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("Expected only the snapshot to be left behind, found %v files", len(files))
+	}
+
+	loaded, err := LoadGame(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.InfectionDeck.Drawn.Contains(CityName("lagos")) {
+		t.Fatal("Expected lagos to still be drawn after loading")
+	}
+}
+
+func TestLoadGameRejectsBadChecksum(t *testing.T) {
+	dir, err := ioutil.TempDir("", "save")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "game_100_infect.json")
+	corrupt := `{"checksum": "0000", "game": {"format_version": 3}}`
+	if err = ioutil.WriteFile(path, []byte(corrupt), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = LoadGame(path); err == nil {
+		t.Fatal("Expected a snapshot with the wrong checksum to fail to load")
+	}
+}
+
+func TestLatestGameSkipsCorruptSnapshots(t *testing.T) {
+	dir, err := ioutil.TempDir("", "save")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	gs, err := NewGame("../data/new_game.json", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = gs.Infect("lagos"); err != nil {
+		t.Fatal(err)
+	}
+	good := filepath.Join(dir, SnapshotFileName("infect", time.Unix(0, 100)))
+	if err = SaveGame(good, gs); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = gs.Infect("essen"); err != nil {
+		t.Fatal(err)
+	}
+	bad := filepath.Join(dir, SnapshotFileName("infect", time.Unix(0, 200)))
+	if err = SaveGame(bad, gs); err != nil {
+		t.Fatal(err)
+	}
+	// cut the newest snapshot short, as if the machine died while writing it
+	data, err := ioutil.ReadFile(bad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(bad, data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, path, err := LatestGame(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != good {
+		t.Fatalf("Expected %v to be loaded, got %v", good, path)
+	}
+	if loaded.InfectionDeck.Drawn.Contains(CityName("essen")) {
+		t.Fatal("Expected the corrupt snapshot's infection of essen to be skipped")
+	}
+}
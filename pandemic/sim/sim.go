@@ -0,0 +1,295 @@
+// Package sim plays the rest of a game of Pandemic forward many times with
+// random draws, to check the closed form probabilities in the pandemic
+// package and to answer questions they can't, such as chained outbreaks.
+package sim
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic"
+)
+
+// A game is lost once this many outbreaks have happened.
+const MaxOutbreaks = 8
+
+type Options struct {
+	Runs int
+	Seed int64
+}
+
+// A Report describes the outcome of every simulated run.
+type Report struct {
+	Runs int
+
+	// How many runs ended with each number of outbreaks.
+	OutbreakCounts map[int]int
+
+	// Fraction of runs that reached the end of the city deck before
+	// losing to outbreaks.
+	RanOutOfCards float64
+
+	// Fraction of runs that lost to outbreaks.
+	LostToOutbreaks float64
+
+	// Fraction of runs in which each city outbroke at least once.
+	CityOutbreaks map[pandemic.CityName]float64
+
+	// Fraction of runs in which each city received a cube during the
+	// current turn. Compare with GameState.ProbabilityOfCity.
+	CityInfectedThisTurn map[pandemic.CityName]float64
+
+	// Fraction of runs in which an epidemic was drawn during the current
+	// turn. Compare with EpidemicAnalysis.
+	EpidemicThisTurn float64
+}
+
+// Simulate plays the rest of the game forward Runs times. Each run starts
+// from the draw step of the current turn, so any city cards already drawn
+// this turn count towards it.
+func Simulate(gs *pandemic.GameState, opts Options) (*Report, error) {
+	if opts.Runs < 1 {
+		return nil, fmt.Errorf("Must simulate at least one run, got %v", opts.Runs)
+	}
+	// games saved before turns were tracked have no turns to play forward
+	if _, err := gs.GameTurns.CurrentTurn(); err != nil {
+		return nil, fmt.Errorf("Can't simulate %v: %v", gs.GameName, err)
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+	report := &Report{
+		Runs:                 opts.Runs,
+		OutbreakCounts:       map[int]int{},
+		CityOutbreaks:        map[pandemic.CityName]float64{},
+		CityInfectedThisTurn: map[pandemic.CityName]float64{},
+	}
+	for i := 0; i < opts.Runs; i++ {
+		clone, err := gs.Clone()
+		if err != nil {
+			return nil, err
+		}
+		result, err := newRun(clone, rng).play()
+		if err != nil {
+			return nil, fmt.Errorf("Run %v failed: %v", i, err)
+		}
+
+		report.OutbreakCounts[clone.Outbreaks]++
+		if result.ranOutOfCards {
+			report.RanOutOfCards++
+		} else {
+			report.LostToOutbreaks++
+		}
+		for _, name := range result.outbroke.Members() {
+			report.CityOutbreaks[pandemic.CityName(name)]++
+		}
+		for _, name := range result.infectedThisTurn.Members() {
+			report.CityInfectedThisTurn[pandemic.CityName(name)]++
+		}
+		if result.epidemicThisTurn {
+			report.EpidemicThisTurn++
+		}
+	}
+
+	runs := float64(opts.Runs)
+	report.RanOutOfCards /= runs
+	report.LostToOutbreaks /= runs
+	report.EpidemicThisTurn /= runs
+	for city := range report.CityOutbreaks {
+		report.CityOutbreaks[city] /= runs
+	}
+	for city := range report.CityInfectedThisTurn {
+		report.CityInfectedThisTurn[city] /= runs
+	}
+	return report, nil
+}
+
+// OutbreakProbability is the fraction of runs that ended with at least
+// the given number of outbreaks.
+func (r *Report) OutbreakProbability(atLeast int) float64 {
+	var count int
+	for outbreaks, runs := range r.OutbreakCounts {
+		if outbreaks >= atLeast {
+			count += runs
+		}
+	}
+	return float64(count) / float64(r.Runs)
+}
+
+type runResult struct {
+	ranOutOfCards    bool
+	epidemicThisTurn bool
+	outbroke         pandemic.Set
+	infectedThisTurn pandemic.Set
+}
+
+type run struct {
+	gs     *pandemic.GameState
+	rng    *rand.Rand
+	deck   []*pandemic.CityCard
+	result runResult
+}
+
+func newRun(gs *pandemic.GameState, rng *rand.Rand) *run {
+	return &run{
+		gs:  gs,
+		rng: rng,
+		result: runResult{
+			outbroke:         pandemic.Set{},
+			infectedThisTurn: pandemic.Set{},
+		},
+	}
+}
+
+func (r *run) play() (runResult, error) {
+	if err := r.shuffleCityDeck(); err != nil {
+		return r.result, err
+	}
+	firstTurn := true
+	for {
+		turn, err := r.gs.GameTurns.CurrentTurn()
+		if err != nil {
+			return r.result, err
+		}
+		for draw := turn.Draws(); draw < pandemic.CityCardsPerTurn; draw++ {
+			if len(r.deck) == 0 {
+				r.result.ranOutOfCards = true
+				return r.result, nil
+			}
+			card := r.deck[0]
+			r.deck = r.deck[1:]
+			if err := r.drawCityCard(card, firstTurn); err != nil {
+				return r.result, err
+			}
+			if r.lost() {
+				return r.result, nil
+			}
+		}
+		for i := 0; i < r.gs.InfectionRate; i++ {
+			if err := r.infect(firstTurn); err != nil {
+				return r.result, err
+			}
+			if r.lost() {
+				return r.result, nil
+			}
+		}
+		// once the infection deck runs dry there is nothing left to
+		// infect, so the infect step can't be completed.
+		if _, err := r.gs.NextTurn(true); err != nil {
+			return r.result, err
+		}
+		firstTurn = false
+	}
+}
+
+func (r *run) lost() bool {
+	return r.gs.Outbreaks >= MaxOutbreaks
+}
+
+// shuffleCityDeck orders the rest of the city deck. One of the striation
+// scenarios still possible is chosen at random, and each remaining epidemic
+// is placed at random within what is left of its striation.
+func (r *run) shuffleCityDeck() error {
+	cityDeck := r.gs.CityDeck
+	unshuffled := cityDeck.RemainingNonEpidemics()
+	remaining := make([]*pandemic.CityCard, len(unshuffled))
+	for i, j := range r.rng.Perm(len(unshuffled)) {
+		remaining[i] = unshuffled[j]
+	}
+
+	scenarios := cityDeck.PossibleStriations()
+	if len(scenarios) == 0 {
+		return fmt.Errorf("No striation scenarios are consistent with the city deck")
+	}
+	index := cityDeck.DrawIndex()
+	epidemicAt := map[int]bool{}
+	start := 0
+	for i, count := range scenarios[r.rng.Intn(len(scenarios))] {
+		end := start + count
+		if i >= cityDeck.EpidemicsDrawn() && end > index {
+			first := start
+			if index > first {
+				first = index
+			}
+			epidemicAt[first+r.rng.Intn(end-first)] = true
+		}
+		start = end
+	}
+
+	r.deck = []*pandemic.CityCard{}
+	for position := index; len(remaining) > 0 || len(epidemicAt) > 0; position++ {
+		if epidemicAt[position] {
+			r.deck = append(r.deck, &pandemic.CityCard{IsEpidemic: true})
+			delete(epidemicAt, position)
+			continue
+		}
+		if len(remaining) == 0 {
+			return fmt.Errorf("Ran out of city cards before placing every epidemic")
+		}
+		r.deck = append(r.deck, remaining[0])
+		remaining = remaining[1:]
+	}
+	return nil
+}
+
+func (r *run) drawCityCard(card *pandemic.CityCard, firstTurn bool) error {
+	if !card.IsEpidemic {
+		if err := r.gs.DrawCard(card.Name()); err != nil {
+			return err
+		}
+		if !card.IsCity() {
+			return nil
+		}
+		city, err := r.gs.GetCity(card.CityName)
+		if err != nil {
+			return err
+		}
+		if pandemic.DataForDisease(city.Disease).InfectOnCityDraw && city.NumInfections < 3 {
+			r.recordInfection(card.CityName, firstTurn)
+			outbreaks, err := r.gs.AddCube(card.CityName)
+			r.recordOutbreaks(outbreaks)
+			return err
+		}
+		return nil
+	}
+
+	if firstTurn {
+		r.result.epidemicThisTurn = true
+	}
+	if len(r.gs.InfectionDeck.Striations) == 0 {
+		// nothing to pull from the bottom, so only increase and intensify.
+		_, err := r.gs.Epidemic("")
+		return err
+	}
+	bottom := r.gs.InfectionDeck.BottomStriation().Members()
+	city := pandemic.CityName(bottom[r.rng.Intn(len(bottom))])
+	r.recordInfection(city, firstTurn)
+	outbreaks, err := r.gs.Epidemic(city)
+	r.recordOutbreaks(outbreaks)
+	return err
+}
+
+func (r *run) infect(firstTurn bool) error {
+	if len(r.gs.InfectionDeck.Striations) == 0 {
+		// every infection card has been drawn; nothing left to infect.
+		return nil
+	}
+	top := r.gs.InfectionDeck.TopStriation().Members()
+	city := pandemic.CityName(top[r.rng.Intn(len(top))])
+	r.recordInfection(city, firstTurn)
+	outbreaks, err := r.gs.Infect(city)
+	r.recordOutbreaks(outbreaks)
+	return err
+}
+
+func (r *run) recordInfection(city pandemic.CityName, firstTurn bool) {
+	if firstTurn {
+		if data, err := r.gs.GetCity(city); err == nil && !data.Quarantined && !r.gs.IsEradicated(data.Disease) {
+			r.result.infectedThisTurn.Add(city)
+		}
+	}
+}
+
+func (r *run) recordOutbreaks(outbreaks []pandemic.CityName) {
+	for _, city := range outbreaks {
+		r.result.outbroke.Add(city)
+	}
+}
@@ -0,0 +1,69 @@
+package sim
+
+import (
+	"math"
+	"testing"
+
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic"
+)
+
+func newTestGame(t *testing.T) *pandemic.GameState {
+	gs, err := pandemic.NewGame("../../data/new_game.json", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gs
+}
+
+func TestSimulateIsRepeatable(t *testing.T) {
+	gs := newTestGame(t)
+	first, err := Simulate(gs, Options{Runs: 20, Seed: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Simulate(gs, Options{Runs: 20, Seed: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for outbreaks, runs := range first.OutbreakCounts {
+		if second.OutbreakCounts[outbreaks] != runs {
+			t.Fatalf("Expected the same seed to give the same outbreaks, got %v and %v", first.OutbreakCounts, second.OutbreakCounts)
+		}
+	}
+	if math.Abs(first.RanOutOfCards+first.LostToOutbreaks-1) > 1e-9 {
+		t.Fatalf("Every run should either run out of cards or lose to outbreaks: %+v", first)
+	}
+	if gs.GameTurns.CurTurn != 0 || gs.CityDeck.EpidemicsDrawn() != 0 {
+		t.Fatal("Simulating should not change the game being simulated")
+	}
+}
+
+func TestSimulateMatchesProbabilityOfCity(t *testing.T) {
+	gs := newTestGame(t)
+	report, err := Simulate(gs, Options{Runs: 2000, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Nothing has been drawn yet, so this turn's epidemic chance is 2 draws
+	// out of the first striation.
+	expected := gs.CityDeck.EpidemicAnalysis()
+	if diff := math.Abs(report.EpidemicThisTurn - (expected.FirstCardProbability + expected.SecondCardProbability)); diff > 0.05 {
+		t.Errorf("Simulated epidemic chance %v is too far from %+v", report.EpidemicThisTurn, expected)
+	}
+	for _, city := range []pandemic.CityName{"lagos", "essen", "tokyo"} {
+		closedForm := gs.ProbabilityOfCity(city)
+		if diff := math.Abs(report.CityInfectedThisTurn[city] - closedForm); diff > 0.05 {
+			t.Errorf("Simulated infection chance for %v was %v, expected about %v", city, report.CityInfectedThisTurn[city], closedForm)
+		}
+	}
+}
+
+func TestSimulateNeedsPlayers(t *testing.T) {
+	gs, err := pandemic.LoadGame("../../may/game_1471404516098025204_i.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Simulate(gs, Options{Runs: 1}); err == nil {
+		t.Fatal("Expected a game with no players to be refused")
+	}
+}
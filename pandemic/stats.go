@@ -0,0 +1,181 @@
+package pandemic
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// GameStats summarizes a single finished (or abandoned) game.
+type GameStats struct {
+	Game                   string              `json:"game"`
+	Outbreaks              int                 `json:"outbreaks"`
+	Turns                  int                 `json:"turns"`
+	EpidemicDraws          []int               `json:"epidemic_draws"`
+	PredictedEpidemicDraws []float64           `json:"predicted_epidemic_draws"`
+	CardsDrawn             map[string]int      `json:"cards_drawn"`
+	CurableOnTurn          map[DiseaseType]int `json:"curable_on_turn"`
+	Infections             map[CityName]int    `json:"infections"`
+}
+
+type CityInfections struct {
+	City       CityName `json:"city"`
+	Infections int      `json:"infections"`
+}
+
+// CampaignStats collects the stats of every game played so far.
+type CampaignStats struct {
+	Games        []GameStats      `json:"games"`
+	MostInfected []CityInfections `json:"most_infected"`
+}
+
+// FinalState loads the last known state of the game saved in dir. Games
+// with a journal are rebuilt from it; otherwise the newest readable snapshot
+// in dir or any folder below it is used.
+func FinalState(dir string) (*GameState, error) {
+	journalPath := filepath.Join(dir, JournalFileName)
+	if _, err := os.Stat(journalPath); err == nil {
+		in, err := os.Open(journalPath)
+		if err != nil {
+			return nil, err
+		}
+		defer in.Close()
+		_, gs, err := ReadJournal(in, ioutil.Discard)
+		return gs, err
+	}
+
+	steps := []*ReplayStep{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if step, ok := snapshotStep(path); ok {
+			steps = append(steps, step)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("No saved games found in %v", dir)
+	}
+	gs, _ := loadNewest(steps)
+	if gs == nil {
+		return nil, fmt.Errorf("No readable saved games found in %v", dir)
+	}
+	return gs, nil
+}
+
+// StatsFor calculates the stats of a single game from its final state.
+func StatsFor(gs *GameState) GameStats {
+	stats := GameStats{
+		Game:                   gs.GameName,
+		Outbreaks:              gs.Outbreaks,
+		EpidemicDraws:          []int{},
+		PredictedEpidemicDraws: []float64{},
+		CardsDrawn:             map[string]int{},
+		CurableOnTurn:          map[DiseaseType]int{},
+		Infections:             map[CityName]int{},
+	}
+	for _, city := range *gs.Cities {
+		stats.Infections[city.Name] = city.NumInfections
+	}
+
+	deck := gs.CityDeck
+	for i, card := range deck.Drawn[len(deck.StartCities):] {
+		if card.IsEpidemic {
+			stats.EpidemicDraws = append(stats.EpidemicDraws, i)
+		}
+	}
+	if deck.NumEpidemics() > 0 {
+		model := generateProbabilityModel(deck.Total()-len(deck.StartCities), deck.NumEpidemics())
+		for i := range stats.EpidemicDraws {
+			stats.PredictedEpidemicDraws = append(stats.PredictedEpidemicDraws, model.ExpectedEpidemicIndex(i))
+		}
+	}
+
+	if gs.GameTurns == nil {
+		return stats
+	}
+	stats.Turns = len(gs.GameTurns.Turns)
+
+	// Follow each player's hand from their start cards through their draws.
+	// Trades and discards aren't recorded per turn, so this tells us when a
+	// disease could first have been cured by someone holding onto every
+	// card they drew.
+	held := map[string]map[DiseaseType]int{}
+	for _, player := range gs.GameTurns.PlayerOrder {
+		stats.CardsDrawn[player.HumanName] = 0
+		held[player.HumanName] = map[DiseaseType]int{}
+		for _, card := range player.StartCards {
+			if city, err := gs.Cities.GetCity(CityName(card)); err == nil {
+				held[player.HumanName][city.Disease]++
+			}
+		}
+	}
+	for turnNumber, turn := range gs.GameTurns.Turns {
+		name := turn.Player.HumanName
+		stats.CardsDrawn[name] += len(turn.DrawnCards)
+		if held[name] == nil {
+			held[name] = map[DiseaseType]int{}
+		}
+		for _, card := range turn.DrawnCards {
+			if city, err := gs.Cities.GetCity(card.CityName); card.IsCity() && err == nil {
+				held[name][city.Disease]++
+			}
+		}
+		for _, dt := range CurableDiseases() {
+			if _, ok := stats.CurableOnTurn[dt]; ok {
+				continue
+			}
+			required, canCure := CardsToCure(turn.Player, dt)
+			if canCure && held[name][dt] >= required {
+				stats.CurableOnTurn[dt] = turnNumber + 1
+			}
+		}
+	}
+	return stats
+}
+
+// StatsForCampaign calculates stats for every game, along with the cities
+// that ended games with the most cubes on them.
+func StatsForCampaign(games []*GameState) CampaignStats {
+	campaign := CampaignStats{
+		Games:        []GameStats{},
+		MostInfected: []CityInfections{},
+	}
+	totals := map[CityName]int{}
+	for _, gs := range games {
+		stats := StatsFor(gs)
+		campaign.Games = append(campaign.Games, stats)
+		for city, infections := range stats.Infections {
+			totals[city] += infections
+		}
+	}
+	for city, infections := range totals {
+		if infections > 0 {
+			campaign.MostInfected = append(campaign.MostInfected, CityInfections{city, infections})
+		}
+	}
+	sort.Sort(byInfections(campaign.MostInfected))
+	return campaign
+}
+
+type byInfections []CityInfections
+
+func (b byInfections) Len() int { return len(b) }
+
+func (b byInfections) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+
+func (b byInfections) Less(i, j int) bool {
+	if b[i].Infections != b[j].Infections {
+		return b[i].Infections > b[j].Infections
+	}
+	return b[i].City < b[j].City
+}
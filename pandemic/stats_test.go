@@ -0,0 +1,51 @@
+package pandemic
+
+import (
+	"testing"
+)
+
+func TestExpectedEpidemicIndex(t *testing.T) {
+	// [2,1] and [1,2]: the first epidemic is expected at 0.25, the second at 1.75
+	model := generateProbabilityModel(3, 2)
+	if expected := model.ExpectedEpidemicIndex(0); expected != 0.25 {
+		t.Errorf("Expected first epidemic at 0.25, got %v", expected)
+	}
+	if expected := model.ExpectedEpidemicIndex(1); expected != 1.75 {
+		t.Errorf("Expected second epidemic at 1.75, got %v", expected)
+	}
+}
+
+func TestStatsFor(t *testing.T) {
+	gs, err := NewGame("../data/new_game.json", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Will starts with chennai and delhi, both black. Two more black
+	// cards make black curable on the first turn.
+	for _, card := range []CardName{"baghdad", "cairo"} {
+		if err = gs.DrawCard(card); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = gs.NextTurn(false); err == nil {
+		t.Fatal("Should not move on before infecting")
+	}
+	gs.NextTurn(true)
+	if _, err = gs.Epidemic("lagos"); err != nil {
+		t.Fatal(err)
+	}
+	stats := StatsFor(gs)
+
+	if len(stats.EpidemicDraws) != 1 || stats.EpidemicDraws[0] != 2 {
+		t.Errorf("Expected an epidemic on the third draw, got %v", stats.EpidemicDraws)
+	}
+	if stats.CardsDrawn["Will"] != 2 || stats.CardsDrawn["MacRae"] != 0 {
+		t.Errorf("Unexpected cards drawn: %v", stats.CardsDrawn)
+	}
+	if turn, ok := stats.CurableOnTurn[Black.Type]; !ok || turn != 1 {
+		t.Errorf("Expected black to be curable on turn 1, got %v", stats.CurableOnTurn)
+	}
+	if stats.Infections["lagos"] != 3 {
+		t.Errorf("Expected lagos to have 3 cubes, got %v", stats.Infections["lagos"])
+	}
+}
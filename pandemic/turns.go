@@ -1,6 +1,7 @@
 package pandemic
 
 import (
+	"encoding/json"
 	"fmt"
 )
 
@@ -11,8 +12,109 @@ type GameTurns struct {
 }
 
 type Turn struct {
-	Player     *Player     `json:"player"`
+	Player     *Player
+	DrawnCards []*CityCard
+	Epidemics  int
+	Infections int
+}
+
+// savedTurn is how a Turn is saved. The player is stored by ID and linked
+// back to the player in GameTurns.PlayerOrder when the turns are loaded.
+type savedTurn struct {
+	PlayerID   int         `json:"player_id"`
 	DrawnCards []*CityCard `json:"drawn_cards"`
+	Epidemics  int         `json:"epidemics"`
+	Infections int         `json:"infections"`
+}
+
+func (t Turn) MarshalJSON() ([]byte, error) {
+	saved := savedTurn{
+		DrawnCards: t.DrawnCards,
+		Epidemics:  t.Epidemics,
+		Infections: t.Infections,
+	}
+	if t.Player != nil {
+		saved.PlayerID = t.Player.ID
+	}
+	return json.Marshal(saved)
+}
+
+// UnmarshalJSON leaves Player as a placeholder holding only what is needed
+// to find the real player. GameTurns swaps in the real player once the
+// player order has been loaded.
+func (t *Turn) UnmarshalJSON(data []byte) error {
+	var saved savedTurn
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+	t.Player = &Player{ID: saved.PlayerID}
+	t.DrawnCards = saved.DrawnCards
+	t.Epidemics = saved.Epidemics
+	t.Infections = saved.Infections
+	return nil
+}
+
+type TurnPhase string
+
+// A turn moves through these phases in order. Drawing an epidemic counts
+// towards the city cards drawn for the turn and is resolved as soon as it
+// is drawn, so the epidemic phase only lasts until the first infection.
+const (
+	ActionsPhase  = TurnPhase("actions")
+	DrawPhase     = TurnPhase("draw")
+	EpidemicPhase = TurnPhase("epidemic")
+	InfectPhase   = TurnPhase("infect")
+	DonePhase     = TurnPhase("done")
+)
+
+// Draws is the number of cards drawn from the city deck this turn,
+// including epidemics.
+func (t *Turn) Draws() int {
+	return len(t.DrawnCards) + t.Epidemics
+}
+
+// Phase works out where the turn is from what has been drawn so far.
+func (t *Turn) Phase(infectionRate int) TurnPhase {
+	draws := t.Draws()
+	switch {
+	case draws >= CityCardsPerTurn && t.Infections >= infectionRate:
+		return DonePhase
+	case t.Infections > 0:
+		return InfectPhase
+	case draws == 0:
+		return ActionsPhase
+	case draws < CityCardsPerTurn:
+		return DrawPhase
+	case t.Epidemics > 0:
+		return EpidemicPhase
+	}
+	return InfectPhase
+}
+
+func (t *GameTurns) UnmarshalJSON(data []byte) error {
+	type savedGameTurns GameTurns // without this method, to avoid recursing
+	var saved savedGameTurns
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+	*t = GameTurns(saved)
+	for i, turn := range t.Turns {
+		player, err := t.playerByID(turn.Player.ID)
+		if err != nil {
+			return fmt.Errorf("Turn %v: %v", i+1, err)
+		}
+		turn.Player = player
+	}
+	return nil
+}
+
+func (t *GameTurns) playerByID(id int) (*Player, error) {
+	for _, player := range t.PlayerOrder {
+		if player.ID == id {
+			return player, nil
+		}
+	}
+	return nil, fmt.Errorf("No player with ID %v", id)
 }
 
 func (t *GameTurns) AddPlayer(p *Player) error {
@@ -24,6 +126,9 @@ func (t *GameTurns) AddPlayer(p *Player) error {
 	// 		return fmt.Errorf("%v has already been added to the game", p.HumanName)
 	// 	}
 	// }
+	if p.ID == 0 {
+		p.ID = len(t.PlayerOrder) + 1
+	}
 	t.PlayerOrder = append(t.PlayerOrder, p)
 	if len(t.PlayerOrder) == 1 {
 		t.Turns = append(t.Turns, t.addTurn()) // create the first turn once we have a player
@@ -61,7 +166,19 @@ func (t *GameTurns) RemainingTurnsFor(remainingCityCards int, name string) int {
 	return base
 }
 
+func (t *GameTurns) GetPlayer(name string) (*Player, error) {
+	for _, player := range t.PlayerOrder {
+		if player.HumanName == name {
+			return player, nil
+		}
+	}
+	return nil, fmt.Errorf("No player named %v", name)
+}
+
 func (t *GameTurns) CurrentTurn() (*Turn, error) {
+	if t == nil {
+		return nil, fmt.Errorf("This game has no players")
+	}
 	if len(t.PlayerOrder) < 2 {
 		return nil, fmt.Errorf("Need at least two players before starting the game, currently have %v", len(t.PlayerOrder))
 	}
@@ -84,18 +201,6 @@ func (t *GameTurns) addTurn() *Turn {
 	}
 }
 
-func (t *GameTurns) AddDrawnToCurrent(card *CityCard) error {
-	turn, err := t.CurrentTurn()
-	if err != nil {
-		return err
-	}
-	if len(turn.DrawnCards) == CityCardsPerTurn {
-		return fmt.Errorf("Already drew %v cards this turn", CityCardsPerTurn)
-	}
-	turn.DrawnCards = append(turn.DrawnCards, card)
-	return nil
-}
-
 func InitGameTurns(ps ...*Player) *GameTurns {
 	turns := &GameTurns{
 		0,
@@ -1,6 +1,11 @@
 package pandemic
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -49,3 +54,113 @@ func TestRemainingTurns(t *testing.T) {
 		}
 	}
 }
+
+func TestTurnPhases(t *testing.T) {
+	gs, err := NewGame("../data/new_game.json", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	turn, err := gs.GameTurns.CurrentTurn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectPhase := func(expected TurnPhase) {
+		if phase := turn.Phase(gs.InfectionRate); phase != expected {
+			t.Fatalf("Expected to be in the %v phase, got %v", expected, phase)
+		}
+	}
+
+	expectPhase(ActionsPhase)
+	if err = gs.DrawCard("milan"); err != nil {
+		t.Fatal(err)
+	}
+	expectPhase(DrawPhase)
+	if _, err = gs.NextTurn(false); err == nil {
+		t.Fatal("Should not move on with a draw left")
+	}
+	if _, err = gs.Epidemic("lagos"); err != nil {
+		t.Fatal(err)
+	}
+	expectPhase(EpidemicPhase)
+	if err = gs.DrawCard("paris"); err == nil {
+		t.Fatal("The epidemic should count as the second draw")
+	}
+	if _, err = gs.Infect("lagos"); err != nil {
+		t.Fatal(err)
+	}
+	expectPhase(InfectPhase)
+	if _, err = gs.NextTurn(false); err == nil {
+		t.Fatal("Should not move on with an infection left")
+	}
+	if _, err = gs.Infect("essen"); err != nil {
+		t.Fatal(err)
+	}
+	expectPhase(DonePhase)
+	if _, err = gs.NextTurn(false); err != nil {
+		t.Fatal(err)
+	}
+	if turn, _ = gs.GameTurns.CurrentTurn(); turn.Player.HumanName != "MacRae" {
+		t.Fatalf("Expected MacRae's turn, got %v", turn.Player.HumanName)
+	}
+	expectPhase(ActionsPhase)
+}
+
+func TestSaveRoundTripMidTurn(t *testing.T) {
+	gs, err := NewGame("../data/new_game.json", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = gs.DrawCard("milan"); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "roundtrip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	data, err := json.Marshal(gs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"player_id":1`) {
+		t.Fatalf("Expected turns to refer to players by ID: %s", data)
+	}
+	saveFile := filepath.Join(dir, "game.json")
+	if err = ioutil.WriteFile(saveFile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadGame(saveFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	turn, _ := loaded.GameTurns.CurrentTurn()
+	if turn.Player != loaded.GameTurns.PlayerOrder[0] {
+		t.Fatal("Expected the current turn to point at the first player in the turn order")
+	}
+
+	// Finishing the turn should play out the same in both games.
+	for _, game := range []*GameState{gs, loaded} {
+		if err = game.DrawCard("paris"); err != nil {
+			t.Fatal(err)
+		}
+		for _, city := range []CityName{"lagos", "essen"} {
+			if _, err = game.Infect(city); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err = game.NextTurn(false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	will, _ := loaded.GameTurns.GetPlayer("Will")
+	if len(will.Cards) != 4 {
+		t.Fatalf("Expected Will to hold 4 cards after reloading, got %v", len(will.Cards))
+	}
+	before, _ := json.Marshal(gs)
+	after, _ := json.Marshal(loaded)
+	if string(before) != string(after) {
+		t.Fatalf("Expected the reloaded game to match the original\n%s\n%s", before, after)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic"
+	"github.com/jroimartin/gocui"
+)
+
+// StartReplay steps through a saved game one snapshot at a time. The left
+// and right arrow keys move back and forward, and up and down jump to the
+// previous or next step that broke the rules.
+func (p *PandemicView) StartReplay(steps []*pandemic.ReplayStep) {
+	gui, err := gocui.NewGui(gocui.OutputNormal)
+	if err != nil {
+		p.logger.Fatalf("Could not init GUI: %v", err)
+	}
+	defer gui.Close()
+
+	cur := 0
+	gui.SetManagerFunc(func(gui *gocui.Gui) error {
+		width, height := gui.Size()
+		step := steps[cur]
+		game := p.replayState(steps, cur)
+
+		if game != nil {
+			// earlier steps may have had more striations than this one
+			for i := len(game.InfectionDeck.Striations); i <= pandemic.EpidemicsPerGame; i++ {
+				gui.DeleteView(fmt.Sprintf("Infection %v", i))
+			}
+			p.renderStriations(game, gui, 0, height/2, width)
+			p.renderCityDeckAndTurns(game, gui, 0, height/2, width/2, height)
+		}
+		p.renderReplayStep(gui, steps, cur, width/2, height/2, width, height)
+		p.logger.Infof("Replaying %v", step.File)
+		return nil
+	})
+
+	move := func(delta int) func(*gocui.Gui, *gocui.View) error {
+		return func(gui *gocui.Gui, view *gocui.View) error {
+			if next := cur + delta; next >= 0 && next < len(steps) {
+				cur = next
+			}
+			return nil
+		}
+	}
+	jumpToProblem := func(delta int) func(*gocui.Gui, *gocui.View) error {
+		return func(gui *gocui.Gui, view *gocui.View) error {
+			for next := cur + delta; next >= 0 && next < len(steps); next += delta {
+				if len(steps[next].Problems) > 0 || steps[next].LoadError != nil {
+					cur = next
+					break
+				}
+			}
+			return nil
+		}
+	}
+	bindings := []struct {
+		key     gocui.Key
+		handler func(*gocui.Gui, *gocui.View) error
+	}{
+		{gocui.KeyArrowRight, move(1)},
+		{gocui.KeyArrowLeft, move(-1)},
+		{gocui.KeyArrowDown, jumpToProblem(1)},
+		{gocui.KeyArrowUp, jumpToProblem(-1)},
+		{gocui.KeyCtrlC, func(gui *gocui.Gui, view *gocui.View) error { return gocui.ErrQuit }},
+	}
+	for _, binding := range bindings {
+		p.terminateIfErr(gui.SetKeybinding("", binding.key, gocui.ModNone, binding.handler), "could not establish replay keybinding", gui)
+	}
+
+	if err := gui.MainLoop(); err != nil && err != gocui.ErrQuit {
+		gui.Close()
+		p.logger.Fatalf("Error in replay main loop: %v", err)
+	}
+}
+
+// Steps that could not be loaded show the last state we could read.
+func (p *PandemicView) replayState(steps []*pandemic.ReplayStep, cur int) *pandemic.GameState {
+	for i := cur; i >= 0; i-- {
+		if steps[i].State == nil {
+			continue
+		}
+		if _, err := steps[i].State.GameTurns.CurrentTurn(); err == nil {
+			return steps[i].State
+		}
+	}
+	return nil
+}
+
+func (p *PandemicView) renderReplayStep(gui *gocui.Gui, steps []*pandemic.ReplayStep, cur int, topX, topY, bottomX, bottomY int) {
+	view, err := gui.SetView("Replay", topX, topY, bottomX, bottomY)
+	p.terminateIfErr(err, "Could not set up replay view", gui)
+	view.Clear()
+	view.Wrap = true
+	view.Title = "Replay (← → step, ↑ ↓ problems)"
+
+	step := steps[cur]
+	fmt.Fprintf(view, "Step %v of %v: %v\n", cur+1, len(steps), filepath.Base(step.File))
+	fmt.Fprintln(view, p.colorHighlight(step.Summary()))
+	if step.LoadError != nil {
+		fmt.Fprintln(view, p.colorOhFuck("Could not load snapshot: %v", step.LoadError))
+	}
+	for _, problem := range step.Problems {
+		fmt.Fprintln(view, p.colorOhFuck(problem))
+	}
+	for _, change := range step.Changes {
+		fmt.Fprintf(view, " - %v\n", change)
+	}
+	if step.State != nil {
+		fmt.Fprintf(view, "Outbreaks: %v  Infection rate: %v\n", step.State.Outbreaks, step.State.InfectionRate)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic"
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic/console"
+)
+
+// A scriptResult is printed for each command when running with
+// --format json.
+type scriptResult struct {
+	Line    int    `json:"line"`
+	Command string `json:"command"`
+	Output  string `json:"output"`
+	Error   string `json:"error,omitempty"`
+}
+
+// openScriptGame starts a new game when run is given a --month, and
+// otherwise loads one the same way as the load command.
+func openScriptGame(wd string) (*pandemic.Journal, *pandemic.GameState, error) {
+	if *runMonth == "" {
+		return openGame(wd, *runFile, *runLatest, *runDir, *runDryRun)
+	}
+	gameState, err := pandemic.NewGame(filepath.Join(wd, *runNewGameFile), *runMonth)
+	if err != nil {
+		return nil, nil, err
+	}
+	return startJournal(gameState, *runDryRun)
+}
+
+// runScript runs each line of the script as a console command, stopping at
+// the first command that fails. Blank lines and lines starting with # are
+// skipped, so scripts can be written up from paper notes with comments.
+func runScript(out io.Writer, script string, dispatcher *console.Dispatcher, format string) error {
+	var in io.Reader = os.Stdin
+	if script != "-" {
+		fd, err := os.Open(script)
+		if err != nil {
+			return err
+		}
+		defer fd.Close()
+		in = fd
+	}
+
+	scanner := bufio.NewScanner(in)
+	encoder := json.NewEncoder(out)
+	for line := 1; scanner.Scan(); line++ {
+		command := strings.TrimSpace(scanner.Text())
+		if command == "" || strings.HasPrefix(command, "#") {
+			continue
+		}
+		var output bytes.Buffer
+		err := dispatcher.Execute(&output, command)
+
+		if format == "json" {
+			result := scriptResult{Line: line, Command: command, Output: output.String()}
+			if err != nil {
+				result.Error = err.Error()
+			}
+			if encodeErr := encoder.Encode(result); encodeErr != nil {
+				return encodeErr
+			}
+		} else {
+			fmt.Fprint(out, output.String())
+		}
+		if err != nil {
+			return fmt.Errorf("line %v: %v: %v", line, command, err)
+		}
+	}
+	return scanner.Err()
+}
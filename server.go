@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic"
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic/console"
+)
+
+// apiServer exposes the game being played in the terminal over HTTP, so
+// everyone at the table can look up numbers without asking the operator.
+// Only loopback addresses are accepted, since the API can change the game.
+// Listening on loopback doesn't stop other web pages open in a browser on
+// the same machine from reaching it, so requests must also name a loopback
+// Host and come from no Origin or a loopback one.
+type apiServer struct {
+	view       *PandemicView
+	dispatcher *console.Dispatcher
+	game       *pandemic.GameState
+
+	// dashboard also serves the browser dashboard and its live updates.
+	dashboard bool
+}
+
+type cityProbability struct {
+	City            pandemic.CityName    `json:"city"`
+	Disease         pandemic.DiseaseType `json:"disease"`
+	Infections      int                  `json:"infections"`
+	Quarantined     bool                 `json:"quarantined,omitempty"`
+	ResearchStation bool                 `json:"research_station,omitempty"`
+	Probability     float64              `json:"probability"`
+	CubeProbability float64              `json:"cube_probability"`
+	CanOutbreak     bool                 `json:"can_outbreak"`
+}
+
+type playerCuring struct {
+	Player   string                           `json:"player"`
+	Current  bool                             `json:"current"`
+	Location pandemic.CityName                `json:"location,omitempty"`
+	Cards    []pandemic.CardName              `json:"cards"`
+	Curing   map[pandemic.DiseaseType]float64 `json:"curing"`
+}
+
+type commandRequest struct {
+	Command string `json:"command"`
+}
+
+type commandResponse struct {
+	Command string `json:"command"`
+	Output  string `json:"output"`
+	Error   string `json:"error,omitempty"`
+}
+
+// newAPIServer shares the view's game and dispatcher, but runs commands
+// without the terminal's colors.
+func newAPIServer(view *PandemicView, game *pandemic.GameState) *apiServer {
+	plain := *view.dispatcher
+	plain.Style = console.PlainStyle
+	return &apiServer{view: view, dispatcher: &plain, game: game}
+}
+
+// ListenAndServe serves the API on addr until it fails. addr must be a
+// loopback address such as 127.0.0.1:8080.
+func (s *apiServer) ListenAndServe(addr string) error {
+	if err := checkLoopback(addr); err != nil {
+		return err
+	}
+	return http.ListenAndServe(addr, s.handler())
+}
+
+func (s *apiServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/game", s.handleGame)
+	mux.HandleFunc("/api/epidemics", s.handleEpidemics)
+	mux.HandleFunc("/api/cities", s.handleCities)
+	mux.HandleFunc("/api/players", s.handlePlayers)
+	mux.HandleFunc("/api/commands", s.handleCommand)
+	if s.dashboard {
+		mux.HandleFunc("/", s.handleDashboardPage)
+		mux.HandleFunc("/api/dashboard", s.handleDashboard)
+		mux.HandleFunc("/api/events", s.handleEvents)
+	}
+	return onlyLoopback(mux)
+}
+
+// onlyLoopback turns away requests that reached the server through another
+// host name, as a DNS rebinding attack would, or that were sent by a page
+// from another site.
+func onlyLoopback(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isLoopbackHost(r.Host) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("%v is not a loopback host", r.Host))
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || !isLoopbackHost(u.Host) {
+				writeError(w, http.StatusForbidden, fmt.Errorf("Requests from %v are not accepted", origin))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if !isLoopbackHost(host) {
+		return fmt.Errorf("%v is not a loopback address; use 127.0.0.1 or localhost", addr)
+	}
+	return nil
+}
+
+// isLoopbackHost reports whether host, with or without a port, names this
+// machine.
+func isLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (s *apiServer) handleGame(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "GET") {
+		return
+	}
+	s.view.mu.Lock()
+	defer s.view.mu.Unlock()
+	writeJSON(w, http.StatusOK, s.game)
+}
+
+func (s *apiServer) handleEpidemics(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "GET") {
+		return
+	}
+	s.view.mu.Lock()
+	defer s.view.mu.Unlock()
+	writeJSON(w, http.StatusOK, s.game.CityDeck.EpidemicAnalysis())
+}
+
+// handleCities reports the chance of each city being infected this turn,
+// or of a single city when ?city= is given a prefix of its name.
+func (s *apiServer) handleCities(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "GET") {
+		return
+	}
+	s.view.mu.Lock()
+	defer s.view.mu.Unlock()
+
+	cities := *s.game.Cities
+	if prefix := r.URL.Query().Get("city"); prefix != "" {
+		city, err := s.game.Cities.GetCityByPrefix(prefix)
+		if err != nil {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		cities = []*pandemic.City{city}
+	}
+	probabilities := []cityProbability{}
+	for _, city := range cities {
+		probabilities = append(probabilities, s.cityProbability(city))
+	}
+	writeJSON(w, http.StatusOK, probabilities)
+}
+
+func (s *apiServer) cityProbability(city *pandemic.City) cityProbability {
+	return cityProbability{
+		City:            city.Name,
+		Disease:         city.Disease,
+		Infections:      city.NumInfections,
+		Quarantined:     city.Quarantined,
+		ResearchStation: city.ResearchStation,
+		Probability:     s.game.ProbabilityOfCity(city.Name),
+		CubeProbability: s.game.ProbabilityOfCube(city.Name),
+		CanOutbreak:     s.game.CanOutbreak(city.Name),
+	}
+}
+
+func (s *apiServer) handlePlayers(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "GET") {
+		return
+	}
+	s.view.mu.Lock()
+	defer s.view.mu.Unlock()
+	writeJSON(w, http.StatusOK, s.playersCuring())
+}
+
+func (s *apiServer) playersCuring() []playerCuring {
+	cur, _ := s.game.GameTurns.CurrentTurn()
+	players := []playerCuring{}
+	for _, player := range s.game.GameTurns.PlayerOrder {
+		curing := playerCuring{
+			Player:   player.HumanName,
+			Current:  cur != nil && cur.Player == player,
+			Location: player.Location,
+			Cards:    []pandemic.CardName{},
+			Curing:   map[pandemic.DiseaseType]float64{},
+		}
+		for _, card := range player.Cards {
+			curing.Cards = append(curing.Cards, card.Name())
+		}
+		for _, dt := range s.game.CurableDiseases() {
+			curing.Curing[dt] = s.game.ProbabilityOfCuring(player, dt)
+		}
+		players = append(players, curing)
+	}
+	return players
+}
+
+// handleCommand runs a console command, given as JSON such as
+// {"command": "infect lagos"}. Only JSON is accepted, since browsers won't
+// send it to another site without asking the server first.
+func (s *apiServer) handleCommand(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "POST") {
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		writeError(w, http.StatusUnsupportedMediaType, fmt.Errorf("Commands must be sent as application/json"))
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var req commandRequest
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	command := strings.Trim(req.Command, "\n\t\r ")
+	if command == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("No command given"))
+		return
+	}
+
+	s.view.mu.Lock()
+	defer s.view.mu.Unlock()
+	defer s.view.changes.notify()
+	var out bytes.Buffer
+	response := commandResponse{Command: command}
+	status := http.StatusOK
+	if err := s.dispatcher.Execute(&out, command); err != nil {
+		response.Error = err.Error()
+		status = http.StatusBadRequest
+		fmt.Fprintln(&out, err)
+	}
+	response.Output = out.String()
+	s.view.showRemoteCommand(command, response.Output)
+	writeJSON(w, status, response)
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%v only accepts %v", r.URL.Path, method))
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		status = http.StatusInternalServerError
+		data, _ = json.Marshal(map[string]string{"error": err.Error()})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic"
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic/console"
+)
+
+func newTestServer(t *testing.T) (*apiServer, *pandemic.GameState) {
+	gs, err := pandemic.NewGame("data/new_game.json", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal, err := pandemic.NewJournal(gs, ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	view := NewView(logger, console.NewDispatcher(gs, journal))
+	server := newAPIServer(view, gs)
+	server.dashboard = true
+	return server, gs
+}
+
+func request(s *apiServer, method, path, contentType, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Host = "127.0.0.1:8080"
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	s.handler().ServeHTTP(w, r)
+	return w
+}
+
+func TestGetEndpoints(t *testing.T) {
+	s, _ := newTestServer(t)
+	for _, path := range []string{"/api/game", "/api/epidemics", "/api/cities", "/api/players", "/api/dashboard"} {
+		w := request(s, "GET", path, "", "")
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected %v to succeed, got %v: %v", path, w.Code, w.Body)
+			continue
+		}
+		var decoded interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &decoded); err != nil {
+			t.Errorf("Expected %v to return JSON: %v", path, err)
+		}
+	}
+	if w := request(s, "GET", "/", "", ""); w.Code != http.StatusOK || !strings.Contains(w.Header().Get("Content-Type"), "text/html") {
+		t.Errorf("Expected the dashboard page, got %v", w.Code)
+	}
+}
+
+func TestCitiesByPrefix(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	w := request(s, "GET", "/api/cities?city=lag", "", "")
+	var cities []cityProbability
+	if err := json.Unmarshal(w.Body.Bytes(), &cities); err != nil {
+		t.Fatal(err)
+	}
+	if len(cities) != 1 || cities[0].City != "lagos" {
+		t.Fatalf("Expected only lagos, got %+v", cities)
+	}
+	if w = request(s, "GET", "/api/cities?city=atlantis", "", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("Expected an unknown city to be not found, got %v", w.Code)
+	}
+}
+
+func TestPostCommand(t *testing.T) {
+	s, gs := newTestServer(t)
+
+	w := request(s, "POST", "/api/commands", "application/json", `{"command": "infect lagos"}`)
+	var response commandResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || response.Output != "Infected lagos\n" || response.Error != "" {
+		t.Fatalf("Unexpected response to infect: %v %+v", w.Code, response)
+	}
+	if !gs.InfectionDeck.Drawn.Contains(pandemic.CityName("lagos")) {
+		t.Fatal("Expected the command to change the game")
+	}
+
+	w = request(s, "POST", "/api/commands", "application/json", `{"command": "infect lagos"}`)
+	response = commandResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusBadRequest || response.Error == "" {
+		t.Fatalf("Expected infecting lagos twice to fail, got %v %+v", w.Code, response)
+	}
+}
+
+func TestPostCommandRejectsBadRequests(t *testing.T) {
+	s, gs := newTestServer(t)
+
+	cases := []struct {
+		contentType string
+		body        string
+		status      int
+	}{
+		{"text/plain", "infect lagos", http.StatusUnsupportedMediaType},
+		{"", "infect lagos", http.StatusUnsupportedMediaType},
+		{"application/json", "", http.StatusBadRequest},
+		{"application/json", `{"command": " "}`, http.StatusBadRequest},
+		{"application/json", `infect lagos`, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		if w := request(s, "POST", "/api/commands", c.contentType, c.body); w.Code != c.status {
+			t.Errorf("Expected %q sent as %q to get %v, got %v", c.body, c.contentType, c.status, w.Code)
+		}
+	}
+	if gs.InfectionDeck.Drawn.Contains(pandemic.CityName("lagos")) {
+		t.Fatal("Expected rejected requests not to change the game")
+	}
+}
+
+func TestWrongMethod(t *testing.T) {
+	s, _ := newTestServer(t)
+	if w := request(s, "GET", "/api/commands", "", ""); w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "POST" {
+		t.Errorf("Expected GET /api/commands to be refused, got %v", w.Code)
+	}
+	if w := request(s, "POST", "/api/game", "application/json", "{}"); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected POST /api/game to be refused, got %v", w.Code)
+	}
+}
+
+func TestForeignRequestsRefused(t *testing.T) {
+	s, gs := newTestServer(t)
+
+	r := httptest.NewRequest("POST", "/api/commands", strings.NewReader(`{"command": "infect lagos"}`))
+	r.Host = "127.0.0.1:8080"
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Origin", "http://evil.example.com")
+	w := httptest.NewRecorder()
+	s.handler().ServeHTTP(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected a request from another site to be refused, got %v", w.Code)
+	}
+
+	r = httptest.NewRequest("GET", "/api/game", nil)
+	r.Host = "rebound.example.com:8080"
+	w = httptest.NewRecorder()
+	s.handler().ServeHTTP(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected a request for another host to be refused, got %v", w.Code)
+	}
+
+	r = httptest.NewRequest("POST", "/api/commands", strings.NewReader(`{"command": "infect lagos"}`))
+	r.Host = "localhost:8080"
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Origin", "http://localhost:8080")
+	w = httptest.NewRecorder()
+	s.handler().ServeHTTP(w, r)
+	if w.Code != http.StatusOK || !gs.InfectionDeck.Drawn.Contains(pandemic.CityName("lagos")) {
+		t.Errorf("Expected a request from the dashboard's own page to run, got %v: %v", w.Code, w.Body)
+	}
+}
+
+func TestCheckLoopback(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1:8080", "localhost:8080", "[::1]:8080"} {
+		if err := checkLoopback(addr); err != nil {
+			t.Errorf("Expected %v to be accepted: %v", addr, err)
+		}
+	}
+	for _, addr := range []string{"0.0.0.0:8080", "192.168.1.10:8080", ":8080"} {
+		if err := checkLoopback(addr); err == nil {
+			t.Errorf("Expected %v to be refused", addr)
+		}
+	}
+}
+
+func TestEventsStream(t *testing.T) {
+	s, _ := newTestServer(t)
+	server := httptest.NewServer(s.handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("Expected an event stream, got %v", resp.Header.Get("Content-Type"))
+	}
+	events := bufio.NewReader(resp.Body)
+	nextState := func() dashboardState {
+		for {
+			line, err := events.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.HasPrefix(line, "data: ") {
+				var state dashboardState
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &state); err != nil {
+					t.Fatal(err)
+				}
+				return state
+			}
+		}
+	}
+	first := nextState()
+
+	post, err := http.Post(server.URL+"/api/commands", "application/json", strings.NewReader(`{"command": "infect lagos"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	post.Body.Close()
+	second := nextState()
+	if len(first.Drawn) != 0 || len(second.Drawn) != 1 || second.Drawn[0].City != "lagos" {
+		t.Fatalf("Expected a new state after the command, got %+v", second)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic"
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic/sim"
+)
+
+const riskiestCitiesShown = 10
+
+// printSimulation plays the game saved in dir forward and compares the
+// results with the closed form probabilities shown while playing.
+func printSimulation(out io.Writer, dir string, runs int, seed int64) error {
+	gs, err := pandemic.FinalState(dir)
+	if err != nil {
+		return err
+	}
+	report, err := sim.Simulate(gs, sim.Options{Runs: runs, Seed: seed})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Simulated %v games from %v\n", report.Runs, gs.GameName)
+	fmt.Fprintf(out, "Ran out of player cards: %.3f\n", report.RanOutOfCards)
+	fmt.Fprintf(out, "Lost to outbreaks:       %.3f\n", report.LostToOutbreaks)
+	analysis := gs.CityDeck.EpidemicAnalysis()
+	fmt.Fprintf(out, "Epidemic this turn:      %.3f (predicted %.3f)\n\n", report.EpidemicThisTurn, analysis.FirstCardProbability+analysis.SecondCardProbability)
+
+	table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "Outbreaks\tGames")
+	counts := []int{}
+	for outbreaks := range report.OutbreakCounts {
+		counts = append(counts, outbreaks)
+	}
+	sort.Ints(counts)
+	for _, outbreaks := range counts {
+		fmt.Fprintf(table, "%v\t%v\n", outbreaks, report.OutbreakCounts[outbreaks])
+	}
+	table.Flush()
+
+	cities := byOutbreakRisk{gs.Cities.CityNames(), report}
+	sort.Sort(cities)
+	fmt.Fprintln(out)
+	table = tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "City\tOutbreaks\tInfected this turn\tPredicted")
+	for i, city := range cities.names {
+		if i == riskiestCitiesShown {
+			break
+		}
+		fmt.Fprintf(table, "%v\t%.3f\t%.3f\t%.3f\n", city, report.CityOutbreaks[city], report.CityInfectedThisTurn[city], gs.ProbabilityOfCity(city))
+	}
+	return table.Flush()
+}
+
+type byOutbreakRisk struct {
+	names  []pandemic.CityName
+	report *sim.Report
+}
+
+func (b byOutbreakRisk) Len() int { return len(b.names) }
+
+func (b byOutbreakRisk) Swap(i, j int) { b.names[i], b.names[j] = b.names[j], b.names[i] }
+
+func (b byOutbreakRisk) Less(i, j int) bool {
+	return b.report.CityOutbreaks[b.names[i]] > b.report.CityOutbreaks[b.names[j]]
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic"
+)
+
+const mostInfectedShown = 10
+
+// printStats loads the final state of every month folder found in dir and
+// reports on them as a table or as JSON.
+func printStats(out io.Writer, dir string, format string) error {
+	games := []*pandemic.GameState{}
+	for _, month := range months {
+		monthDir := filepath.Join(dir, month)
+		if info, err := os.Stat(monthDir); err != nil || !info.IsDir() {
+			continue
+		}
+		gs, err := pandemic.FinalState(monthDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping %v: %v\n", month, err)
+			continue
+		}
+		games = append(games, gs)
+	}
+	if len(games) == 0 {
+		return fmt.Errorf("No saved games found in %v", dir)
+	}
+	campaign := pandemic.StatsForCampaign(games)
+
+	if format == "json" {
+		data, err := json.MarshalIndent(campaign, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	}
+
+	table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "Game\tTurns\tOutbreaks\tEpidemic draws (predicted)\tCards drawn\tCurable on turn")
+	for _, game := range campaign.Games {
+		epidemics := []string{}
+		for i, draw := range game.EpidemicDraws {
+			epidemics = append(epidemics, fmt.Sprintf("%v (%.1f)", draw, game.PredictedEpidemicDraws[i]))
+		}
+		drawn := []string{}
+		for player, count := range game.CardsDrawn {
+			drawn = append(drawn, fmt.Sprintf("%v %v", player, count))
+		}
+		sort.Strings(drawn)
+		curable := []string{}
+		for dt, turn := range game.CurableOnTurn {
+			curable = append(curable, fmt.Sprintf("%v %v", dt, turn))
+		}
+		sort.Strings(curable)
+		fmt.Fprintf(table, "%v\t%v\t%v\t%v\t%v\t%v\n",
+			game.Game,
+			game.Turns,
+			game.Outbreaks,
+			strings.Join(epidemics, ", "),
+			strings.Join(drawn, ", "),
+			strings.Join(curable, ", "))
+	}
+	table.Flush()
+
+	fmt.Fprintln(out, "\nMost infected cities at the end of a game:")
+	table = tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	for i, city := range campaign.MostInfected {
+		if i == mostInfectedShown {
+			break
+		}
+		fmt.Fprintf(table, "%v\t%v\n", city.City, city.Infections)
+	}
+	return table.Flush()
+}
@@ -3,11 +3,16 @@ package main
 import (
 	"fmt"
 	"math"
+	"os"
+	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic"
+	"github.com/anthonybishopric/pandemic-nerd-hurd/pandemic/console"
 	"github.com/fatih/color"
 	"github.com/jroimartin/gocui"
 )
@@ -20,17 +25,84 @@ type PandemicView struct {
 	colorHighlight      func(string, ...interface{}) string
 	colorOhFuck         func(string, ...interface{}) string
 	fileSaveCounter     int
+	dispatcher          *console.Dispatcher
+
+	// mu guards the game, which the HTTP API shares with the terminal.
+	mu  *sync.Mutex
+	gui *gocui.Gui
+
+	// changes tells dashboards to redraw after every command.
+	changes *broadcaster
+
+	// Pressing Tab again on a line it just completed moves on to the next
+	// candidate.
+	completions   []string
+	completion    int
+	completedLine string
+
+	// How many commands back the up arrow has gone, and what was being
+	// typed before it was pressed.
+	historyBack int
+	draft       string
 }
 
-func NewView(logger *logrus.Logger) *PandemicView {
-	return &PandemicView{
+// NewView creates a view that runs console commands with the dispatcher,
+// coloring their output to match the rest of the view.
+func NewView(logger *logrus.Logger, dispatcher *console.Dispatcher) *PandemicView {
+	p := &PandemicView{
 		logger:              logger,
+		dispatcher:          dispatcher,
+		mu:                  &sync.Mutex{},
+		changes:             newBroadcaster(),
 		colorWhiteHighlight: color.New(color.FgBlack).Add(color.BgWhite).SprintfFunc(),
 		colorAllGood:        color.New(color.FgGreen).Add(color.BgBlack).SprintfFunc(),
 		colorWarning:        color.New(color.FgYellow).Add(color.BgBlack).SprintfFunc(),
 		colorHighlight:      color.New(color.FgRed).SprintfFunc(),
 		colorOhFuck:         color.New(color.FgBlack).Add(color.BgRed).Add(color.BlinkSlow).SprintfFunc(),
 	}
+	if dispatcher != nil {
+		dispatcher.Style = console.Style{
+			AllGood:   p.colorAllGood,
+			Warning:   p.colorWarning,
+			Highlight: p.colorHighlight,
+			OhFuck:    p.colorOhFuck,
+		}
+	}
+	return p
+}
+
+func (p *PandemicView) runCommand(consoleView *gocui.View, commandView *gocui.View) error {
+	commandBuffer := strings.Trim(commandView.Buffer(), "\n\t\r ")
+	if commandBuffer == "" {
+		return nil
+	}
+	defer commandView.SetCursor(commandView.Origin())
+	defer commandView.Clear()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	defer p.changes.notify()
+	p.historyBack = 0
+	if err := p.dispatcher.Execute(consoleView, commandBuffer); err != nil {
+		fmt.Fprintln(consoleView, p.colorWarning("%v", err))
+	}
+	return nil
+}
+
+// showRemoteCommand echoes a command run through the HTTP API in the
+// Console and redraws the game. Callers must hold p.mu.
+func (p *PandemicView) showRemoteCommand(command, output string) {
+	if p.gui == nil {
+		return
+	}
+	p.gui.Update(func(gui *gocui.Gui) error {
+		consoleView, err := gui.View("Console")
+		if err != nil {
+			return nil
+		}
+		fmt.Fprintf(consoleView, "%v %v\n%v", p.colorHighlight("api>"), command, output)
+		return nil
+	})
 }
 
 func (p *PandemicView) Start(game *pandemic.GameState) {
@@ -40,8 +112,13 @@ func (p *PandemicView) Start(game *pandemic.GameState) {
 		p.logger.Errorln("Could not init GUI: %v", err)
 	}
 	defer gui.Close()
+	p.mu.Lock()
+	p.gui = gui
+	p.mu.Unlock()
 
 	gui.SetManagerFunc(func(gui *gocui.Gui) error {
+		p.mu.Lock()
+		defer p.mu.Unlock()
 		width, height := gui.Size()
 
 		p.renderCommandsView(game, gui, width)
@@ -61,6 +138,20 @@ func (p *PandemicView) Start(game *pandemic.GameState) {
 	}
 }
 
+// saveOnExit writes a snapshot next to the game's journal, so the game can
+// be picked up again with load --latest.
+func (p *PandemicView) saveOnExit(game *pandemic.GameState) error {
+	if err := os.MkdirAll(game.GameName, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(game.GameName, pandemic.SnapshotFileName("exit", time.Now()))
+	if err := pandemic.SaveGame(path, game); err != nil {
+		return err
+	}
+	p.logger.Infof("Saved %v", path)
+	return nil
+}
+
 func (p *PandemicView) renderCommandsView(game *pandemic.GameState, gui *gocui.Gui, maxX int) {
 	commandView, err := gui.SetView("Commands", 0, 0, maxX, 2)
 	if err != nil && err != gocui.ErrUnknownView {
@@ -103,6 +194,7 @@ func (p *PandemicView) renderCityDeckAndTurns(game *pandemic.GameState, gui *goc
 	fmt.Fprintf(cityView, "%v  %v  ", p.iconFor(pandemic.Blue.Type), game.CityDeck.RemainingCardsWith(pandemic.Blue.Type, game.Cities))
 	fmt.Fprintf(cityView, "%v  %v  ", p.iconFor(pandemic.Yellow.Type), game.CityDeck.RemainingCardsWith(pandemic.Yellow.Type, game.Cities))
 	fmt.Fprintf(cityView, "%v  %v\n", p.iconFor(pandemic.Faded.Type), game.CityDeck.RemainingCardsWith(pandemic.Faded.Type, game.Cities))
+	fmt.Fprintf(cityView, "Player discard pile: %v  Removed from game: %v\n", len(game.PlayerDiscard), len(game.RemovedFromGame))
 
 	turnView, err := gui.SetView("Turns", topX, topY+(bottomY-topY)/2, bottomX, bottomY)
 	if err != nil && err != gocui.ErrUnknownView {
@@ -115,7 +207,9 @@ func (p *PandemicView) renderCityDeckAndTurns(game *pandemic.GameState, gui *goc
 
 	cur, err := game.GameTurns.CurrentTurn()
 	if err != nil {
-		p.logger.Fatalln(err)
+		// Games saved before turns were tracked have no players to show.
+		fmt.Fprintln(turnView, err)
+		return
 	}
 	for _, player := range game.GameTurns.PlayerOrder {
 		if cur.Player == player {
@@ -126,7 +220,16 @@ func (p *PandemicView) renderCityDeckAndTurns(game *pandemic.GameState, gui *goc
 		fmt.Fprint(turnView, " ")
 	}
 	fmt.Fprintln(turnView)
+	for _, player := range game.GameTurns.PlayerOrder {
+		location := "?"
+		if !player.Location.Empty() {
+			location = player.Location.String()
+		}
+		fmt.Fprintf(turnView, "%v: %v  ", player.HumanName, location)
+	}
+	fmt.Fprintln(turnView)
 	fmt.Fprintf(turnView, "%v has %v turns left\n", cur.Player.HumanName, game.GameTurns.RemainingTurnsFor(game.CityDeck.RemainingCards(), cur.Player.HumanName))
+	fmt.Fprintf(turnView, "Phase: %v (drawn %v/%v, infected %v/%v)\n", p.colorHighlight(string(cur.Phase(game.InfectionRate))), cur.Draws(), pandemic.CityCardsPerTurn, cur.Infections, game.InfectionRate)
 	if cur.Player.Character != nil && cur.Player.Character.TurnMessage != "" {
 		fmt.Fprintln(turnView, p.colorAllGood(cur.Player.Character.TurnMessage))
 	}
@@ -144,8 +247,8 @@ func (p *PandemicView) renderCityDeckAndTurns(game *pandemic.GameState, gui *goc
 	fmt.Fprintln(turnView, "\nCure Likelihood: ")
 
 	// print curability stats
-	curability := byCurability{pandemic.CurableDiseases(), make(map[pandemic.DiseaseType]float64), make(map[pandemic.DiseaseType]maxCurability)}
-	for _, dt := range pandemic.CurableDiseases() {
+	curability := byCurability{game.CurableDiseases(), make(map[pandemic.DiseaseType]float64), make(map[pandemic.DiseaseType]maxCurability)}
+	for _, dt := range curability.dts {
 		playerProb := game.ProbabilityOfCuring(cur.Player, dt)
 		curability.curability[dt] = playerProb
 		curability.maxCurability[dt] = maxCurability{playerProb, cur.Player}
@@ -168,6 +271,11 @@ func (p *PandemicView) renderCityDeckAndTurns(game *pandemic.GameState, gui *goc
 		}
 		fmt.Fprintf(turnView, "%v  \U00002697  %v %v \n", p.iconFor(dt), p.colorProbabilityOfCure(curability.curability[dt]), maxStr)
 	}
+	for _, data := range game.DiseaseData {
+		if data.Status != pandemic.Uncured {
+			fmt.Fprintf(turnView, "%v  %v\n", p.iconFor(data.Type), p.colorAllGood(data.Status.String()))
+		}
+	}
 }
 
 func (p *PandemicView) iconFor(dt pandemic.DiseaseType) string {
@@ -231,10 +339,13 @@ func (p *PandemicView) terminateIfErr(err error, msg string, gui *gocui.Gui) {
 
 func (p *PandemicView) setUpKeyBindings(game *pandemic.GameState, gui *gocui.Gui, commandView string) {
 	err := gui.SetKeybinding("", gocui.KeyCtrlC, gocui.ModNone, func(gui *gocui.Gui, view *gocui.View) error {
-		// when we get a ctrl-C we exit the game
-		gui.Close()
-		p.logger.Fatalf("Buh bye") // TODO: save
-		return nil
+		// when we get a ctrl-C we save a snapshot and exit the game
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if err := p.saveOnExit(game); err != nil {
+			p.logger.Errorf("Could not save the game on exit: %v", err)
+		}
+		return gocui.ErrQuit
 	})
 	p.terminateIfErr(err, "could not establish graceful termination keybinding", gui)
 	err = gui.SetKeybinding(commandView, gocui.KeyEnter, gocui.ModNone, func(gui *gocui.Gui, view *gocui.View) error {
@@ -244,29 +355,93 @@ func (p *PandemicView) setUpKeyBindings(game *pandemic.GameState, gui *gocui.Gui
 			p.logger.Fatalln("Console view not found, game view not set up correctly")
 			return nil
 		}
-		return p.runCommand(game, consoleView, view)
+		return p.runCommand(consoleView, view)
 	})
 	err = gui.SetKeybinding(commandView, gocui.KeyTab, gocui.ModNone, func(gui *gocui.Gui, view *gocui.View) error {
-		cleanBuffer := strings.Trim(view.Buffer(), "\n\t\r ")
-		if cleanBuffer == "" {
-			return nil
-		}
-		words := strings.Split(cleanBuffer, " ")
-		prefix := words[len(words)-1]
-		city, err := game.Cities.GetCityByPrefix(prefix)
+		consoleView, err := gui.View("Console")
 		if err != nil {
 			return nil
 		}
-		words[len(words)-1] = city.Name.String()
-		x, y := view.Cursor()
-		view.Clear()
-		fmt.Fprint(view, strings.Join(words, " "))
-		view.SetCursor(x+len(city.Name.String())-len(prefix), y)
+		p.complete(consoleView, view)
+		return nil
+	})
+	p.terminateIfErr(err, "could not establish keybinding for command view", gui)
+	err = gui.SetKeybinding(commandView, gocui.KeyArrowUp, gocui.ModNone, func(gui *gocui.Gui, view *gocui.View) error {
+		p.recall(view, 1)
+		return nil
+	})
+	p.terminateIfErr(err, "could not establish keybinding for command view", gui)
+	err = gui.SetKeybinding(commandView, gocui.KeyArrowDown, gocui.ModNone, func(gui *gocui.Gui, view *gocui.View) error {
+		p.recall(view, -1)
 		return nil
 	})
 	p.terminateIfErr(err, "could not establish keybinding for command view", gui)
 }
 
+// recall steps through the command history, older for 1 and newer for -1.
+// Stepping forward past the newest command brings back what was being typed.
+func (p *PandemicView) recall(commandView *gocui.View, step int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	back := p.historyBack + step
+	if back < 0 || back > p.dispatcher.History.Len() {
+		return
+	}
+	if p.historyBack == 0 {
+		p.draft = strings.TrimRight(commandView.Buffer(), "\n\r")
+	}
+	p.historyBack = back
+	line := p.draft
+	if back > 0 {
+		line, _ = p.dispatcher.History.Back(back)
+	}
+	commandView.Clear()
+	fmt.Fprint(commandView, line)
+	commandView.SetCursor(len(line), 0)
+}
+
+// complete finishes the word being typed in the command view. When more
+// than one word fits, the candidates are listed in the console and each
+// Tab after that cycles through them.
+func (p *PandemicView) complete(consoleView *gocui.View, commandView *gocui.View) {
+	line := strings.TrimRight(commandView.Buffer(), "\n\r")
+	words := completionWords(line)
+	if len(words) == 0 {
+		return
+	}
+
+	if line == p.completedLine && len(p.completions) > 1 {
+		p.completion = (p.completion + 1) % len(p.completions)
+	} else {
+		p.mu.Lock()
+		p.completions = p.dispatcher.Complete(words)
+		p.mu.Unlock()
+		p.completion = 0
+		if len(p.completions) == 0 {
+			return
+		}
+		if len(p.completions) > 1 {
+			fmt.Fprintln(consoleView, strings.Join(p.completions, "  "))
+		}
+	}
+
+	words[len(words)-1] = p.completions[p.completion]
+	p.completedLine = strings.Join(words, " ")
+	commandView.Clear()
+	fmt.Fprint(commandView, p.completedLine)
+	commandView.SetCursor(len(p.completedLine), 0)
+}
+
+// completionWords splits the line typed so far into words, ending with an
+// empty word when a space has been typed after the last one.
+func completionWords(line string) []string {
+	words := strings.Fields(line)
+	if len(words) > 0 && strings.HasSuffix(line, " ") {
+		words = append(words, "")
+	}
+	return words
+}
+
 func (p *PandemicView) renderConsoleArea(game *pandemic.GameState, gui *gocui.Gui, topX, topY, bottomX, bottomY int) {
 	view, err := gui.SetView("Console", topX, topY, bottomX, bottomY)
 	view.Title = "Console"
@@ -326,6 +501,7 @@ func (p *PandemicView) printCityWithProb(game *pandemic.GameState, view *gocui.V
 	// 	return err
 	// }
 	probability := game.ProbabilityOfCity(city)
+	cubeProbability := game.ProbabilityOfCube(city)
 
 	diseaseEmoji := p.iconFor(cityData.Disease)
 
@@ -334,13 +510,20 @@ func (p *PandemicView) printCityWithProb(game *pandemic.GameState, view *gocui.V
 		infectionRateEmojis += "•"
 	}
 
-	quarantinedEmoji := ""
+	statusEmoji := ""
 	if cityData.Quarantined {
-		quarantinedEmoji = "\u26d4"
+		statusEmoji = "\u26d4"
+	}
+	if cityData.ResearchStation {
+		statusEmoji += "\U0001f3e5"
+	}
+	if cityData.MilitaryBase {
+		statusEmoji += "\u2694"
 	}
 
-	text := fmt.Sprintf("%v %s  %s  %s  %.2f", city[:4], diseaseEmoji, infectionRateEmojis, quarantinedEmoji, probability)
-	if probability == 0.0 {
+	// The second number includes cubes from neighboring outbreaks.
+	text := fmt.Sprintf("%v %s  %s  %s  %.2f %.2f", city[:4], diseaseEmoji, infectionRateEmojis, statusEmoji, probability, cubeProbability)
+	if cubeProbability == 0.0 {
 		fmt.Fprintln(view, p.colorAllGood(text))
 	} else if game.CanOutbreak(city) {
 		fmt.Fprintln(view, p.colorOhFuck(text))
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompletionWords(t *testing.T) {
+	cases := []struct {
+		line     string
+		expected []string
+	}{
+		{"", []string{}},
+		{"   ", []string{}},
+		{"giv", []string{"giv"}},
+		{"give-card  wi", []string{"give-card", "wi"}},
+		{" give-card ", []string{"give-card", ""}},
+		{"give-card  will  ", []string{"give-card", "will", ""}},
+	}
+	for _, c := range cases {
+		if words := completionWords(c.line); !reflect.DeepEqual(words, c.expected) {
+			t.Errorf("Expected %q to split into %q, got %q", c.line, c.expected, words)
+		}
+	}
+}